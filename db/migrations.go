@@ -0,0 +1,231 @@
+package db
+
+import (
+	"database/sql"
+	_ "embed"
+	"fmt"
+)
+
+//go:embed schema.sql
+var schemaSQL string
+
+// migrations are applied in order; the version of a migration is its index
+// plus one. Never edit a migration that has shipped, append a new one instead.
+var migrations = []string{
+	schemaSQL,
+	`CREATE TABLE league_state (
+    id INTEGER PRIMARY KEY CHECK (id = 1),
+    current_week INTEGER NOT NULL,
+    seed INTEGER NOT NULL
+);
+ALTER TABLE teams ADD COLUMN attack INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE teams ADD COLUMN defence INTEGER NOT NULL DEFAULT 0;`,
+	// Leagues become first-class; every team, match and standing belongs to one.
+	// The league stored by earlier versions is kept as league 1. A version 1
+	// database has teams but no league_state row, so its current week is the
+	// last played one. This used to fail on such databases, so changing it
+	// affects no database the migration has run on.
+	`CREATE TABLE leagues (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    current_week INTEGER NOT NULL,
+    seed INTEGER NOT NULL
+);
+INSERT INTO leagues (id, name, current_week, seed)
+SELECT 1, 'Premier League',
+    COALESCE((SELECT current_week FROM league_state), (SELECT MAX(week) FROM matches WHERE played), 0),
+    COALESCE((SELECT seed FROM league_state), 0)
+WHERE EXISTS (SELECT 1 FROM league_state) OR EXISTS (SELECT 1 FROM teams);
+
+CREATE TABLE league_teams (
+    league_id INTEGER NOT NULL REFERENCES leagues(id) ON DELETE CASCADE,
+    id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    strength INTEGER NOT NULL,
+    attack INTEGER NOT NULL DEFAULT 0,
+    defence INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (league_id, id)
+);
+INSERT INTO league_teams (league_id, id, name, strength, attack, defence)
+SELECT 1, id, name, strength, attack, defence FROM teams;
+
+CREATE TABLE league_matches (
+    league_id INTEGER NOT NULL REFERENCES leagues(id) ON DELETE CASCADE,
+    id INTEGER NOT NULL,
+    home_team_id INTEGER NOT NULL,
+    away_team_id INTEGER NOT NULL,
+    home_goals INTEGER,
+    away_goals INTEGER,
+    played BOOLEAN,
+    week INTEGER,
+    PRIMARY KEY (league_id, id),
+    FOREIGN KEY (league_id, home_team_id) REFERENCES league_teams(league_id, id) ON DELETE CASCADE,
+    FOREIGN KEY (league_id, away_team_id) REFERENCES league_teams(league_id, id) ON DELETE CASCADE
+);
+INSERT INTO league_matches (league_id, id, home_team_id, away_team_id, home_goals, away_goals, played, week)
+SELECT 1, id, home_team_id, away_team_id, home_goals, away_goals, played, week FROM matches;
+
+CREATE TABLE league_standings (
+    league_id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    played INTEGER,
+    won INTEGER,
+    drawn INTEGER,
+    lost INTEGER,
+    goals_for INTEGER,
+    goals_against INTEGER,
+    goal_diff INTEGER,
+    points INTEGER,
+    PRIMARY KEY (league_id, team_id),
+    FOREIGN KEY (league_id, team_id) REFERENCES league_teams(league_id, id) ON DELETE CASCADE
+);
+INSERT INTO league_standings (league_id, team_id, played, won, drawn, lost, goals_for, goals_against, goal_diff, points)
+SELECT 1, team_id, played, won, drawn, lost, goals_for, goals_against, goal_diff, points FROM standings;
+
+DROP TABLE standings;
+DROP TABLE matches;
+DROP TABLE teams;
+DROP TABLE league_state;
+ALTER TABLE league_teams RENAME TO teams;
+ALTER TABLE league_matches RENAME TO matches;
+ALTER TABLE league_standings RENAME TO standings;`,
+	// Comma separated tiebreaker rule names; empty means the default chain.
+	`ALTER TABLE leagues ADD COLUMN tiebreakers TEXT NOT NULL DEFAULT '';`,
+	// Undo/redo event log; results are stored as a JSON array.
+	`CREATE TABLE history (
+    league_id INTEGER NOT NULL REFERENCES leagues(id) ON DELETE CASCADE,
+    seq INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    week INTEGER NOT NULL DEFAULT 0,
+    results TEXT NOT NULL DEFAULT '[]',
+    PRIMARY KEY (league_id, seq)
+);
+ALTER TABLE leagues ADD COLUMN history_cursor INTEGER NOT NULL DEFAULT 0;`,
+	// Name of the league's match engine; see services.ParseEngine.
+	`ALTER TABLE leagues ADD COLUMN engine TEXT NOT NULL DEFAULT 'poisson';`,
+	// Match timelines; the half-time score is NULL for matches without one
+	// and events are stored as a JSON array.
+	`ALTER TABLE matches ADD COLUMN half_time_home_goals INTEGER;
+ALTER TABLE matches ADD COLUMN half_time_away_goals INTEGER;
+ALTER TABLE matches ADD COLUMN events TEXT NOT NULL DEFAULT '[]';`,
+	// Team squads; match events refer to players by ID.
+	`CREATE TABLE players (
+    league_id INTEGER NOT NULL,
+    id INTEGER NOT NULL,
+    team_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    position TEXT NOT NULL,
+    rating INTEGER NOT NULL,
+    PRIMARY KEY (league_id, id),
+    FOREIGN KEY (league_id, team_id) REFERENCES teams(league_id, id) ON DELETE CASCADE
+);`,
+	// Knockout cups. Entrants and the bracket are stored as JSON since cup
+	// teams are copies and belong to no league.
+	`CREATE TABLE cups (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    draw TEXT NOT NULL,
+    legs INTEGER NOT NULL,
+    seed INTEGER NOT NULL,
+    engine TEXT NOT NULL,
+    next_match INTEGER NOT NULL,
+    teams TEXT NOT NULL,
+    rounds TEXT NOT NULL
+);`,
+	// Group stage plus knockout tournaments. Each group is a whole league
+	// state and the knockout a cup state, both stored as JSON; knockout is
+	// NULL during the group stage.
+	`CREATE TABLE tournaments (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    seed INTEGER NOT NULL,
+    advance INTEGER NOT NULL,
+    legs INTEGER NOT NULL,
+    engine TEXT NOT NULL,
+    tiebreakers TEXT NOT NULL,
+    entrants TEXT NOT NULL,
+    groups TEXT NOT NULL,
+    knockout TEXT
+);`,
+	// Promotion and relegation pyramids. Each division's current season is
+	// a whole league state, and settled seasons are kept as history; both
+	// are stored as JSON.
+	`CREATE TABLE pyramids (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    seed INTEGER NOT NULL,
+    engine TEXT NOT NULL,
+    tiebreakers TEXT NOT NULL,
+    season INTEGER NOT NULL,
+    divisions TEXT NOT NULL,
+    history TEXT NOT NULL
+);`,
+	// Multi-season leagues. Archived seasons never change, so they are
+	// written once and keep their table, results and strength changes as
+	// JSON.
+	`ALTER TABLE leagues ADD COLUMN season INTEGER NOT NULL DEFAULT 1;
+CREATE TABLE seasons (
+    league_id INTEGER NOT NULL REFERENCES leagues(id) ON DELETE CASCADE,
+    number INTEGER NOT NULL,
+    seed INTEGER NOT NULL,
+    standings TEXT NOT NULL,
+    matches TEXT NOT NULL,
+    changes TEXT NOT NULL,
+    PRIMARY KEY (league_id, number)
+);`,
+	// End-of-season playoffs: the configuration and bracket of the current
+	// season, and the decided playoff of each archived season, as JSON.
+	`ALTER TABLE leagues ADD COLUMN playoff TEXT;
+ALTER TABLE seasons ADD COLUMN playoff TEXT;`,
+	// Player IDs are handed out from a counter so a removed player's ID is
+	// never reused; existing leagues continue after their highest ID.
+	`ALTER TABLE leagues ADD COLUMN next_player_id INTEGER NOT NULL DEFAULT 1;
+UPDATE leagues SET next_player_id = COALESCE((SELECT MAX(id) FROM players WHERE players.league_id = leagues.id), 0) + 1;`,
+	// What rounding left out of each team's developed ratings, as a JSON
+	// object keyed by team ID.
+	`ALTER TABLE leagues ADD COLUMN remainders TEXT NOT NULL DEFAULT '{}';`,
+	// League, cup, tournament and pyramid IDs are handed out from counters
+	// so a deleted record's ID is never reused; existing databases continue
+	// after their highest IDs.
+	`CREATE TABLE id_counters (
+    kind TEXT PRIMARY KEY,
+    next_id INTEGER NOT NULL
+);
+INSERT INTO id_counters (kind, next_id)
+SELECT 'leagues', COALESCE(MAX(id), 0) + 1 FROM leagues
+UNION ALL SELECT 'cups', COALESCE(MAX(id), 0) + 1 FROM cups
+UNION ALL SELECT 'tournaments', COALESCE(MAX(id), 0) + 1 FROM tournaments
+UNION ALL SELECT 'pyramids', COALESCE(MAX(id), 0) + 1 FROM pyramids;`,
+}
+
+// migrate brings the database up to the latest schema version.
+func migrate(conn *sql.DB) error {
+	if _, err := conn.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var current int
+	if err := conn.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return fmt.Errorf("read schema version: %w", err)
+	}
+
+	for i := current; i < len(migrations); i++ {
+		tx, err := conn.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("apply migration %d: %w", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("record migration %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
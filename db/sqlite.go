@@ -0,0 +1,702 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteRepository stores leagues in an SQLite database laid out as in
+// schema.sql plus the migrations in migrations.go.
+type SQLiteRepository struct {
+	conn *sql.DB
+
+	mu sync.Mutex
+	// saved is what the database holds for each league, so saves can
+	// write only the rows that changed.
+	saved map[int]*savedLeague
+}
+
+// OpenSQLite opens (or creates) the database at path and migrates it to the
+// latest schema.
+func OpenSQLite(path string) (*SQLiteRepository, error) {
+	conn, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; serialise access through one connection.
+	conn.SetMaxOpenConns(1)
+
+	if err := migrate(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &SQLiteRepository{conn: conn, saved: make(map[int]*savedLeague)}, nil
+}
+
+// Close closes the underlying database.
+func (r *SQLiteRepository) Close() error {
+	return r.conn.Close()
+}
+
+// LoadLeagues reads every stored league ordered by ID.
+func (r *SQLiteRepository) LoadLeagues() ([]services.LeagueState, error) {
+	rows, err := r.conn.Query(`SELECT id, name, current_week, seed, tiebreakers, history_cursor, engine, season, playoff, next_player_id, remainders FROM leagues ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("load leagues: %w", err)
+	}
+	var states []services.LeagueState
+	for rows.Next() {
+		var state services.LeagueState
+		var tiebreakers, remainders string
+		var playoff sql.NullString
+		if err := rows.Scan(&state.ID, &state.Name, &state.CurrentWeek, &state.Seed, &tiebreakers, &state.Cursor, &state.Engine, &state.Season, &playoff, &state.NextPlayerID, &remainders); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan league: %w", err)
+		}
+		if tiebreakers != "" {
+			state.Tiebreakers = strings.Split(tiebreakers, ",")
+		}
+		if playoff.Valid {
+			if err := json.Unmarshal([]byte(playoff.String), &state.Playoff); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("decode playoff of league %d: %w", state.ID, err)
+			}
+		}
+		if err := json.Unmarshal([]byte(remainders), &state.Remainders); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("decode remainders of league %d: %w", state.ID, err)
+		}
+		states = append(states, state)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range states {
+		if err := r.loadLeague(&states[i]); err != nil {
+			return nil, fmt.Errorf("load league %d: %w", states[i].ID, err)
+		}
+		r.saved[states[i].ID] = newSavedLeague(states[i])
+	}
+	return states, nil
+}
+
+// loadLeague fills in the teams, players, matches and standings of state.
+func (r *SQLiteRepository) loadLeague(state *services.LeagueState) error {
+	rows, err := r.conn.Query(`SELECT id, name, strength, attack, defence FROM teams WHERE league_id = ? ORDER BY id`, state.ID)
+	if err != nil {
+		return err
+	}
+	teams := make(map[int]models.Team)
+	for rows.Next() {
+		var team models.Team
+		if err := rows.Scan(&team.ID, &team.Name, &team.Strength, &team.Attack, &team.Defence); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan team: %w", err)
+		}
+		teams[team.ID] = team
+		state.Teams = append(state.Teams, team)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = r.conn.Query(`SELECT id, team_id, name, position, rating FROM players WHERE league_id = ? ORDER BY rowid`, state.ID)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var player models.Player
+		if err := rows.Scan(&player.ID, &player.TeamID, &player.Name, &player.Position, &player.Rating); err != nil {
+			rows.Close()
+			return fmt.Errorf("scan player: %w", err)
+		}
+		state.Players = append(state.Players, player)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	rows, err = r.conn.Query(`SELECT id, home_team_id, away_team_id, home_goals, away_goals, played, week, half_time_home_goals, half_time_away_goals, events FROM matches WHERE league_id = ? ORDER BY week, id`, state.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var match models.Match
+		var homeID, awayID int
+		var halfTimeHome, halfTimeAway sql.NullInt64
+		var events string
+		if err := rows.Scan(&match.ID, &homeID, &awayID, &match.HomeGoals, &match.AwayGoals, &match.Played, &match.Week, &halfTimeHome, &halfTimeAway, &events); err != nil {
+			return fmt.Errorf("scan match: %w", err)
+		}
+		if halfTimeHome.Valid && halfTimeAway.Valid {
+			match.HalfTime = &models.Score{HomeGoals: int(halfTimeHome.Int64), AwayGoals: int(halfTimeAway.Int64)}
+		}
+		if err := json.Unmarshal([]byte(events), &match.Events); err != nil {
+			return fmt.Errorf("decode events of match %d: %w", match.ID, err)
+		}
+		match.Home = teams[homeID]
+		match.Away = teams[awayID]
+		for len(state.Matches) < match.Week {
+			state.Matches = append(state.Matches, nil)
+		}
+		state.Matches[match.Week-1] = append(state.Matches[match.Week-1], match)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := r.loadHistory(state); err != nil {
+		return err
+	}
+	return r.loadSeasons(state)
+}
+
+// loadHistory reads the league's undo/redo event log.
+func (r *SQLiteRepository) loadHistory(state *services.LeagueState) error {
+	rows, err := r.conn.Query(`SELECT seq, type, created_at, week, results FROM history WHERE league_id = ? ORDER BY seq`, state.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var event services.HistoryEvent
+		var results string
+		if err := rows.Scan(&event.Seq, &event.Type, &event.Time, &event.Week, &results); err != nil {
+			return fmt.Errorf("scan history: %w", err)
+		}
+		if err := json.Unmarshal([]byte(results), &event.Results); err != nil {
+			return fmt.Errorf("decode history %d: %w", event.Seq, err)
+		}
+		state.History = append(state.History, event)
+	}
+	return rows.Err()
+}
+
+// loadSeasons reads the league's archived seasons.
+func (r *SQLiteRepository) loadSeasons(state *services.LeagueState) error {
+	rows, err := r.conn.Query(`SELECT number, seed, standings, matches, changes, playoff FROM seasons WHERE league_id = ? ORDER BY number`, state.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var season services.SeasonRecord
+		var standings, matches, changes string
+		var playoff sql.NullString
+		if err := rows.Scan(&season.Number, &season.Seed, &standings, &matches, &changes, &playoff); err != nil {
+			return fmt.Errorf("scan season: %w", err)
+		}
+		if err := json.Unmarshal([]byte(standings), &season.Table); err != nil {
+			return fmt.Errorf("decode standings of season %d: %w", season.Number, err)
+		}
+		if err := json.Unmarshal([]byte(matches), &season.Matches); err != nil {
+			return fmt.Errorf("decode matches of season %d: %w", season.Number, err)
+		}
+		if err := json.Unmarshal([]byte(changes), &season.Changes); err != nil {
+			return fmt.Errorf("decode changes of season %d: %w", season.Number, err)
+		}
+		if playoff.Valid {
+			if err := json.Unmarshal([]byte(playoff.String), &season.Playoff); err != nil {
+				return fmt.Errorf("decode playoff of season %d: %w", season.Number, err)
+			}
+		}
+		state.Seasons = append(state.Seasons, season)
+	}
+	return rows.Err()
+}
+
+// savedLeague is what the database holds for a league, kept row by row so
+// SaveLeague can write only what changed since the last save.
+type savedLeague struct {
+	teams     map[int]models.Team
+	players   map[int]models.Player
+	matches   map[int]models.Match
+	standings map[int]models.Standing
+	history   []services.HistoryEvent
+	seasons   int
+}
+
+// newSavedLeague indexes the rows of state. Matches keep their own copy of
+// the events so later changes to state do not show through.
+func newSavedLeague(state services.LeagueState) *savedLeague {
+	saved := &savedLeague{
+		teams:     make(map[int]models.Team, len(state.Teams)),
+		players:   make(map[int]models.Player, len(state.Players)),
+		matches:   make(map[int]models.Match),
+		standings: make(map[int]models.Standing, len(state.Standings)),
+		history:   append([]services.HistoryEvent(nil), state.History...),
+		seasons:   len(state.Seasons),
+	}
+	for _, team := range state.Teams {
+		saved.teams[team.ID] = team
+	}
+	for _, player := range state.Players {
+		saved.players[player.ID] = player
+	}
+	for _, weekMatches := range state.Matches {
+		for _, match := range weekMatches {
+			match.Events = append([]models.MatchEvent(nil), match.Events...)
+			saved.matches[match.ID] = match
+		}
+	}
+	for teamID, standing := range state.Standings {
+		saved.standings[teamID] = *standing
+	}
+	return saved
+}
+
+// sameMatchRow reports whether two matches are stored identically. Teams are
+// stored by ID only.
+func sameMatchRow(a, b models.Match) bool {
+	if a.Home.ID != b.Home.ID || a.Away.ID != b.Away.ID || a.HomeGoals != b.HomeGoals || a.AwayGoals != b.AwayGoals ||
+		a.Played != b.Played || a.Week != b.Week || (a.HalfTime == nil) != (b.HalfTime == nil) {
+		return false
+	}
+	if a.HalfTime != nil && *a.HalfTime != *b.HalfTime {
+		return false
+	}
+	return slices.Equal(a.Events, b.Events)
+}
+
+// sameHistoryEvent reports whether two events are the same recorded event.
+// Recorded events never change, so the sequence number, type and time
+// identify one.
+func sameHistoryEvent(a, b services.HistoryEvent) bool {
+	return a.Seq == b.Seq && a.Type == b.Type && a.Time.Equal(b.Time)
+}
+
+// SaveLeague writes a league in a single transaction. Only the rows that
+// changed since the league was last loaded or saved are written: changed
+// teams, players, matches and standings, history events recorded since, and
+// newly archived seasons. A league the repository has not seen yet is
+// written in full.
+func (r *SQLiteRepository) SaveLeague(state services.LeagueState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	playoff, err := nullableJSON(state.Playoff)
+	if err != nil {
+		return err
+	}
+	remainders, err := json.Marshal(state.Remainders)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`INSERT INTO leagues (id, name, current_week, seed, tiebreakers, history_cursor, engine, season, playoff, next_player_id, remainders) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET name = excluded.name, current_week = excluded.current_week, seed = excluded.seed,
+    tiebreakers = excluded.tiebreakers, history_cursor = excluded.history_cursor, engine = excluded.engine, season = excluded.season,
+    playoff = excluded.playoff, next_player_id = excluded.next_player_id, remainders = excluded.remainders`,
+		state.ID, state.Name, state.CurrentWeek, state.Seed, strings.Join(state.Tiebreakers, ","), state.Cursor, state.Engine, state.Season, playoff, state.NextPlayerID, string(remainders)); err != nil {
+		return fmt.Errorf("save league: %w", err)
+	}
+
+	prev, ok := r.saved[state.ID]
+	if !ok {
+		// Whatever is stored for an unknown league is replaced outright
+		for _, stmt := range []string{
+			`DELETE FROM history WHERE league_id = ?`,
+			`DELETE FROM standings WHERE league_id = ?`,
+			`DELETE FROM matches WHERE league_id = ?`,
+			`DELETE FROM players WHERE league_id = ?`,
+			`DELETE FROM teams WHERE league_id = ?`,
+		} {
+			if _, err := tx.Exec(stmt, state.ID); err != nil {
+				return fmt.Errorf("clear league: %w", err)
+			}
+		}
+		prev = newSavedLeague(services.LeagueState{})
+	}
+	next := newSavedLeague(state)
+
+	// Rows that went away are deleted before the teams they refer to
+	for id := range prev.standings {
+		if _, ok := next.standings[id]; !ok {
+			if _, err := tx.Exec(`DELETE FROM standings WHERE league_id = ? AND team_id = ?`, state.ID, id); err != nil {
+				return fmt.Errorf("delete standing %d: %w", id, err)
+			}
+		}
+	}
+	for id := range prev.matches {
+		if _, ok := next.matches[id]; !ok {
+			if _, err := tx.Exec(`DELETE FROM matches WHERE league_id = ? AND id = ?`, state.ID, id); err != nil {
+				return fmt.Errorf("delete match %d: %w", id, err)
+			}
+		}
+	}
+	for id := range prev.players {
+		if _, ok := next.players[id]; !ok {
+			if _, err := tx.Exec(`DELETE FROM players WHERE league_id = ? AND id = ?`, state.ID, id); err != nil {
+				return fmt.Errorf("delete player %d: %w", id, err)
+			}
+		}
+	}
+	for id := range prev.teams {
+		if _, ok := next.teams[id]; !ok {
+			if _, err := tx.Exec(`DELETE FROM teams WHERE league_id = ? AND id = ?`, state.ID, id); err != nil {
+				return fmt.Errorf("delete team %d: %w", id, err)
+			}
+		}
+	}
+
+	for _, team := range state.Teams {
+		if old, ok := prev.teams[team.ID]; ok && old == team {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO teams (league_id, id, name, strength, attack, defence) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (league_id, id) DO UPDATE SET name = excluded.name, strength = excluded.strength, attack = excluded.attack, defence = excluded.defence`,
+			state.ID, team.ID, team.Name, team.Strength, team.Attack, team.Defence); err != nil {
+			return fmt.Errorf("save team %d: %w", team.ID, err)
+		}
+	}
+
+	for _, player := range state.Players {
+		if old, ok := prev.players[player.ID]; ok && old == player {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO players (league_id, id, team_id, name, position, rating) VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT (league_id, id) DO UPDATE SET team_id = excluded.team_id, name = excluded.name, position = excluded.position, rating = excluded.rating`,
+			state.ID, player.ID, player.TeamID, player.Name, player.Position, player.Rating); err != nil {
+			return fmt.Errorf("save player %d: %w", player.ID, err)
+		}
+	}
+
+	for _, weekMatches := range state.Matches {
+		for _, match := range weekMatches {
+			if old, ok := prev.matches[match.ID]; ok && sameMatchRow(old, match) {
+				continue
+			}
+			var halfTimeHome, halfTimeAway sql.NullInt64
+			if match.HalfTime != nil {
+				halfTimeHome = sql.NullInt64{Int64: int64(match.HalfTime.HomeGoals), Valid: true}
+				halfTimeAway = sql.NullInt64{Int64: int64(match.HalfTime.AwayGoals), Valid: true}
+			}
+			events, err := json.Marshal(append([]models.MatchEvent{}, match.Events...))
+			if err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT INTO matches (league_id, id, home_team_id, away_team_id, home_goals, away_goals, played, week, half_time_home_goals, half_time_away_goals, events) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (league_id, id) DO UPDATE SET home_team_id = excluded.home_team_id, away_team_id = excluded.away_team_id,
+    home_goals = excluded.home_goals, away_goals = excluded.away_goals, played = excluded.played, week = excluded.week,
+    half_time_home_goals = excluded.half_time_home_goals, half_time_away_goals = excluded.half_time_away_goals, events = excluded.events`,
+				state.ID, match.ID, match.Home.ID, match.Away.ID, match.HomeGoals, match.AwayGoals, match.Played, match.Week, halfTimeHome, halfTimeAway, string(events)); err != nil {
+				return fmt.Errorf("save match %d: %w", match.ID, err)
+			}
+		}
+	}
+
+	for teamID, st := range state.Standings {
+		if old, ok := prev.standings[teamID]; ok && old == *st {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO standings (league_id, team_id, played, won, drawn, lost, goals_for, goals_against, goal_diff, points) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (league_id, team_id) DO UPDATE SET played = excluded.played, won = excluded.won, drawn = excluded.drawn, lost = excluded.lost,
+    goals_for = excluded.goals_for, goals_against = excluded.goals_against, goal_diff = excluded.goal_diff, points = excluded.points`,
+			state.ID, teamID, st.Played, st.Won, st.Drawn, st.Lost, st.GoalsFor, st.GoalsAgainst, st.GoalDiff, st.Points); err != nil {
+			return fmt.Errorf("save standing %d: %w", teamID, err)
+		}
+	}
+
+	// The log only grows, except that recording after an undo drops the
+	// undone events and a fixture change clears it: keep the common prefix,
+	// delete the rest of the stored log and append the new events.
+	kept := 0
+	for kept < len(prev.history) && kept < len(state.History) && sameHistoryEvent(prev.history[kept], state.History[kept]) {
+		kept++
+	}
+	if kept < len(prev.history) {
+		if _, err := tx.Exec(`DELETE FROM history WHERE league_id = ? AND seq >= ?`, state.ID, prev.history[kept].Seq); err != nil {
+			return fmt.Errorf("truncate history: %w", err)
+		}
+	}
+	for _, event := range state.History[kept:] {
+		results, err := json.Marshal(event.Results)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO history (league_id, seq, type, created_at, week, results) VALUES (?, ?, ?, ?, ?, ?)`,
+			state.ID, event.Seq, event.Type, event.Time, event.Week, string(results)); err != nil {
+			return fmt.Errorf("save history %d: %w", event.Seq, err)
+		}
+	}
+
+	// Archived seasons are immutable, so only new ones are written
+	for _, season := range state.Seasons[min(prev.seasons, len(state.Seasons)):] {
+		standings, err := json.Marshal(season.Table)
+		if err != nil {
+			return err
+		}
+		matches, err := json.Marshal(season.Matches)
+		if err != nil {
+			return err
+		}
+		changes, err := json.Marshal(season.Changes)
+		if err != nil {
+			return err
+		}
+		playoff, err := nullableJSON(season.Playoff)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO seasons (league_id, number, seed, standings, matches, changes, playoff) VALUES (?, ?, ?, ?, ?, ?, ?) ON CONFLICT (league_id, number) DO NOTHING`,
+			state.ID, season.Number, season.Seed, string(standings), string(matches), string(changes), playoff); err != nil {
+			return fmt.Errorf("save season %d: %w", season.Number, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	r.saved[state.ID] = next
+	return nil
+}
+
+// DeleteLeague removes a league; its teams, matches and standings cascade.
+func (r *SQLiteRepository) DeleteLeague(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.conn.Exec(`DELETE FROM leagues WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete league %d: %w", id, err)
+	}
+	delete(r.saved, id)
+	return nil
+}
+
+// LoadNextIDs reads the next ID to give out for each kind of record.
+func (r *SQLiteRepository) LoadNextIDs() (map[string]int, error) {
+	rows, err := r.conn.Query(`SELECT kind, next_id FROM id_counters`)
+	if err != nil {
+		return nil, fmt.Errorf("load ID counters: %w", err)
+	}
+	defer rows.Close()
+
+	next := make(map[string]int)
+	for rows.Next() {
+		var kind string
+		var id int
+		if err := rows.Scan(&kind, &id); err != nil {
+			return nil, fmt.Errorf("scan ID counter: %w", err)
+		}
+		next[kind] = id
+	}
+	return next, rows.Err()
+}
+
+// SaveNextID stores the next ID to give out for a kind of record.
+func (r *SQLiteRepository) SaveNextID(kind string, next int) error {
+	if _, err := r.conn.Exec(`INSERT INTO id_counters (kind, next_id) VALUES (?, ?)
+ON CONFLICT (kind) DO UPDATE SET next_id = excluded.next_id`, kind, next); err != nil {
+		return fmt.Errorf("save %s ID counter: %w", kind, err)
+	}
+	return nil
+}
+
+// LoadCups reads every stored cup ordered by ID.
+func (r *SQLiteRepository) LoadCups() ([]services.CupState, error) {
+	rows, err := r.conn.Query(`SELECT id, name, draw, legs, seed, engine, next_match, teams, rounds FROM cups ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("load cups: %w", err)
+	}
+	defer rows.Close()
+
+	var states []services.CupState
+	for rows.Next() {
+		var state services.CupState
+		var teams, rounds string
+		if err := rows.Scan(&state.ID, &state.Name, &state.Draw, &state.Legs, &state.Seed, &state.Engine, &state.NextMatch, &teams, &rounds); err != nil {
+			return nil, fmt.Errorf("scan cup: %w", err)
+		}
+		if err := json.Unmarshal([]byte(teams), &state.Teams); err != nil {
+			return nil, fmt.Errorf("decode cup %d teams: %w", state.ID, err)
+		}
+		if err := json.Unmarshal([]byte(rounds), &state.Rounds); err != nil {
+			return nil, fmt.Errorf("decode cup %d rounds: %w", state.ID, err)
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// SaveCup replaces the stored copy of a cup.
+func (r *SQLiteRepository) SaveCup(state services.CupState) error {
+	teams, err := json.Marshal(state.Teams)
+	if err != nil {
+		return err
+	}
+	rounds, err := json.Marshal(state.Rounds)
+	if err != nil {
+		return err
+	}
+	if _, err := r.conn.Exec(`INSERT INTO cups (id, name, draw, legs, seed, engine, next_match, teams, rounds) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET name = excluded.name, draw = excluded.draw, legs = excluded.legs, seed = excluded.seed,
+    engine = excluded.engine, next_match = excluded.next_match, teams = excluded.teams, rounds = excluded.rounds`,
+		state.ID, state.Name, state.Draw, state.Legs, state.Seed, state.Engine, state.NextMatch, string(teams), string(rounds)); err != nil {
+		return fmt.Errorf("save cup: %w", err)
+	}
+	return nil
+}
+
+// DeleteCup removes a cup.
+func (r *SQLiteRepository) DeleteCup(id int) error {
+	if _, err := r.conn.Exec(`DELETE FROM cups WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete cup %d: %w", id, err)
+	}
+	return nil
+}
+
+// LoadTournaments reads every stored tournament ordered by ID.
+func (r *SQLiteRepository) LoadTournaments() ([]services.TournamentState, error) {
+	rows, err := r.conn.Query(`SELECT id, name, seed, advance, legs, engine, tiebreakers, entrants, groups, knockout FROM tournaments ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("load tournaments: %w", err)
+	}
+	defer rows.Close()
+
+	var states []services.TournamentState
+	for rows.Next() {
+		var state services.TournamentState
+		var tiebreakers, entrants, groups string
+		var knockout sql.NullString
+		if err := rows.Scan(&state.ID, &state.Name, &state.Seed, &state.Advance, &state.Legs, &state.Engine, &tiebreakers, &entrants, &groups, &knockout); err != nil {
+			return nil, fmt.Errorf("scan tournament: %w", err)
+		}
+		if tiebreakers != "" {
+			state.Tiebreakers = strings.Split(tiebreakers, ",")
+		}
+		if err := json.Unmarshal([]byte(entrants), &state.Entrants); err != nil {
+			return nil, fmt.Errorf("decode tournament %d entrants: %w", state.ID, err)
+		}
+		if err := json.Unmarshal([]byte(groups), &state.Groups); err != nil {
+			return nil, fmt.Errorf("decode tournament %d groups: %w", state.ID, err)
+		}
+		if knockout.Valid {
+			state.Knockout = &services.CupState{}
+			if err := json.Unmarshal([]byte(knockout.String), state.Knockout); err != nil {
+				return nil, fmt.Errorf("decode tournament %d knockout: %w", state.ID, err)
+			}
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// SaveTournament replaces the stored copy of a tournament.
+func (r *SQLiteRepository) SaveTournament(state services.TournamentState) error {
+	entrants, err := json.Marshal(state.Entrants)
+	if err != nil {
+		return err
+	}
+	groups, err := json.Marshal(state.Groups)
+	if err != nil {
+		return err
+	}
+	knockout, err := nullableJSON(state.Knockout)
+	if err != nil {
+		return err
+	}
+	if _, err := r.conn.Exec(`INSERT INTO tournaments (id, name, seed, advance, legs, engine, tiebreakers, entrants, groups, knockout) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET name = excluded.name, seed = excluded.seed, advance = excluded.advance, legs = excluded.legs,
+    engine = excluded.engine, tiebreakers = excluded.tiebreakers, entrants = excluded.entrants, groups = excluded.groups, knockout = excluded.knockout`,
+		state.ID, state.Name, state.Seed, state.Advance, state.Legs, state.Engine, strings.Join(state.Tiebreakers, ","), string(entrants), string(groups), knockout); err != nil {
+		return fmt.Errorf("save tournament: %w", err)
+	}
+	return nil
+}
+
+// DeleteTournament removes a tournament.
+func (r *SQLiteRepository) DeleteTournament(id int) error {
+	if _, err := r.conn.Exec(`DELETE FROM tournaments WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete tournament %d: %w", id, err)
+	}
+	return nil
+}
+
+// LoadPyramids reads every stored pyramid ordered by ID.
+func (r *SQLiteRepository) LoadPyramids() ([]services.PyramidState, error) {
+	rows, err := r.conn.Query(`SELECT id, name, seed, engine, tiebreakers, season, divisions, history FROM pyramids ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("load pyramids: %w", err)
+	}
+	defer rows.Close()
+
+	var states []services.PyramidState
+	for rows.Next() {
+		var state services.PyramidState
+		var tiebreakers, divisions, history string
+		if err := rows.Scan(&state.ID, &state.Name, &state.Seed, &state.Engine, &tiebreakers, &state.Season, &divisions, &history); err != nil {
+			return nil, fmt.Errorf("scan pyramid: %w", err)
+		}
+		if tiebreakers != "" {
+			state.Tiebreakers = strings.Split(tiebreakers, ",")
+		}
+		if err := json.Unmarshal([]byte(divisions), &state.Divisions); err != nil {
+			return nil, fmt.Errorf("decode pyramid %d divisions: %w", state.ID, err)
+		}
+		if err := json.Unmarshal([]byte(history), &state.History); err != nil {
+			return nil, fmt.Errorf("decode pyramid %d history: %w", state.ID, err)
+		}
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// SavePyramid replaces the stored copy of a pyramid.
+func (r *SQLiteRepository) SavePyramid(state services.PyramidState) error {
+	divisions, err := json.Marshal(state.Divisions)
+	if err != nil {
+		return err
+	}
+	history, err := json.Marshal(append([]services.PyramidSeason{}, state.History...))
+	if err != nil {
+		return err
+	}
+	if _, err := r.conn.Exec(`INSERT INTO pyramids (id, name, seed, engine, tiebreakers, season, divisions, history) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT (id) DO UPDATE SET name = excluded.name, seed = excluded.seed, engine = excluded.engine,
+    tiebreakers = excluded.tiebreakers, season = excluded.season, divisions = excluded.divisions, history = excluded.history`,
+		state.ID, state.Name, state.Seed, state.Engine, strings.Join(state.Tiebreakers, ","), state.Season, string(divisions), string(history)); err != nil {
+		return fmt.Errorf("save pyramid: %w", err)
+	}
+	return nil
+}
+
+// DeletePyramid removes a pyramid.
+func (r *SQLiteRepository) DeletePyramid(id int) error {
+	if _, err := r.conn.Exec(`DELETE FROM pyramids WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("delete pyramid %d: %w", id, err)
+	}
+	return nil
+}
+
+// nullableJSON encodes v for a nullable JSON column, storing NULL for nil.
+func nullableJSON[T any](v *T) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
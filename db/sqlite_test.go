@@ -0,0 +1,278 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"league-simulator/models"
+	"league-simulator/services"
+)
+
+func testTeams(n int) []models.Team {
+	teams := make([]models.Team, n)
+	for i := range teams {
+		teams[i] = models.Team{ID: i + 1, Name: fmt.Sprintf("Team %d", i+1), Strength: 4 + i%5}
+	}
+	return teams
+}
+
+func open(t *testing.T, path string) *SQLiteRepository {
+	t.Helper()
+	repo, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+func loadRegistry(t *testing.T, repo *SQLiteRepository) *services.Registry {
+	t.Helper()
+	registry := services.NewRegistry(repo, services.WithSeed(7))
+	if err := registry.Load(); err != nil {
+		t.Fatal(err)
+	}
+	return registry
+}
+
+// leagueView is what a client can see of a league, minus history times.
+type leagueView struct {
+	Teams   []models.Team
+	Players []models.Player
+	Results []string
+	Table   []models.Standing
+	History []string
+	Cursor  int
+	Season  int
+	Seasons []int64
+}
+
+func viewLeague(t *testing.T, league services.LeagueSimulator) leagueView {
+	t.Helper()
+	view := leagueView{Teams: league.Teams(), Table: league.GetStandings(), Season: league.Season()}
+	for _, team := range view.Teams {
+		players, err := league.Players(team.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		view.Players = append(view.Players, players...)
+	}
+	for _, weekMatches := range league.Matches() {
+		for _, match := range weekMatches {
+			view.Results = append(view.Results, fmt.Sprintf("%d week %d: %d %d-%d %d played=%t events=%d",
+				match.ID, match.Week, match.Home.ID, match.HomeGoals, match.AwayGoals, match.Away.ID, match.Played, len(match.Events)))
+		}
+	}
+	history, cursor := league.History()
+	for _, event := range history {
+		results, err := json.Marshal(event.Results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		view.History = append(view.History, fmt.Sprintf("%d %s week %d %s", event.Seq, event.Type, event.Week, results))
+	}
+	view.Cursor = cursor
+	for _, season := range league.Seasons() {
+		view.Seasons = append(view.Seasons, season.Seed)
+	}
+	return view
+}
+
+func TestLeaguesRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "league.db")
+	repo := open(t, path)
+	registry := loadRegistry(t, repo)
+
+	league, err := registry.Create("Round Trip", testTeams(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := registry.Create("Deleted", testTeams(2)); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Delete(2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each step is saved on its own, so the stored rows are built up from
+	// incremental saves: the season ends, development changes the teams,
+	// the history is truncated by recording after an undo, and so on.
+	league.SimulateAll()
+	if _, err := league.NextSeason(); err != nil {
+		t.Fatal(err)
+	}
+	name := "Renamed"
+	if _, err := league.UpdateTeam(2, services.TeamUpdate{Name: &name}); err != nil {
+		t.Fatal(err)
+	}
+	player, err := league.AddPlayer(models.Player{TeamID: 1, Name: "Striker", Position: models.Forward, Rating: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := league.AddPlayer(models.Player{TeamID: 3, Name: "Keeper", Position: models.Goalkeeper, Rating: 60}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := league.SimulateWeek(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := league.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := league.SimulateWeek(); err != nil {
+		t.Fatal(err)
+	}
+	if err := league.EditMatchResult(league.Matches()[0][0].ID, 4, 4); err != nil {
+		t.Fatal(err)
+	}
+	if err := league.RemovePlayer(player.ID); err != nil {
+		t.Fatal(err)
+	}
+	want := viewLeague(t, league)
+	repo.Close()
+
+	reopened := open(t, path)
+	restored, err := loadRegistry(t, reopened).Get(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := viewLeague(t, restored); !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened league differs:\ngot  %+v\nwant %+v", got, want)
+	}
+
+	// The rows written save by save match a league written in one go.
+	states, err := reopened.LoadLeagues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := open(t, filepath.Join(t.TempDir(), "full.db"))
+	for _, state := range states {
+		if err := full.SaveLeague(state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fullStates, err := full.LoadLeagues()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fullStates, states) {
+		t.Errorf("incrementally saved league differs from one saved in full:\ngot  %+v\nwant %+v", states, fullStates)
+	}
+}
+
+func TestSaveWritesOnlyChangedRows(t *testing.T) {
+	repo := open(t, filepath.Join(t.TempDir(), "league.db"))
+	league, err := loadRegistry(t, repo).Create("", testTeams(6))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := func() int {
+		var n int
+		if err := repo.conn.QueryRow(`SELECT total_changes()`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	before := changes()
+	if err := league.SimulateWeek(); err != nil {
+		t.Fatal(err)
+	}
+	// The league row, the week's 3 matches, 6 standings and 1 history event;
+	// the other 27 matches and the teams are left alone.
+	if written := changes() - before; written != 11 {
+		t.Errorf("simulating a week wrote %d rows, want 11", written)
+	}
+}
+
+func TestDeletedIDsAreNotReused(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "league.db")
+	repo := open(t, path)
+	registry := loadRegistry(t, repo)
+	for i := 0; i < 3; i++ {
+		if _, err := registry.Create("", testTeams(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := registry.Delete(3); err != nil {
+		t.Fatal(err)
+	}
+	cup, err := registry.CreateCup("", testTeams(2), services.CupOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.DeleteCup(cup.ID()); err != nil {
+		t.Fatal(err)
+	}
+	repo.Close()
+
+	registry = loadRegistry(t, open(t, path))
+	league, err := registry.Create("", testTeams(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if league.ID() != 4 {
+		t.Errorf("new league got ID %d after league 3 was deleted, want 4", league.ID())
+	}
+	if cup, err = registry.CreateCup("", testTeams(2), services.CupOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if cup.ID() != 2 {
+		t.Errorf("new cup got ID %d after cup 1 was deleted, want 2", cup.ID())
+	}
+}
+
+func TestMigratesAVersion1Database(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "league.db")
+	conn, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		schemaSQL,
+		`CREATE TABLE schema_migrations (version INTEGER PRIMARY KEY)`,
+		`INSERT INTO schema_migrations (version) VALUES (1)`,
+		`INSERT INTO teams (id, name, strength) VALUES (1, 'Chelsea', 80), (2, 'Arsenal', 75)`,
+		`INSERT INTO matches (id, home_team_id, away_team_id, home_goals, away_goals, played, week) VALUES
+    (1, 1, 2, 2, 1, 1, 1), (2, 2, 1, 0, 0, 0, 2)`,
+		`INSERT INTO standings (team_id, played, won, drawn, lost, goals_for, goals_against, goal_diff, points) VALUES
+    (1, 1, 1, 0, 0, 2, 1, 1, 3), (2, 1, 0, 0, 1, 1, 2, -1, 0)`,
+	} {
+		if _, err := conn.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn.Close()
+
+	registry := loadRegistry(t, open(t, path))
+	league, err := registry.Get(1)
+	if err != nil {
+		t.Fatalf("the version 1 league was not kept as league 1: %v", err)
+	}
+	if teams := league.Teams(); len(teams) != 2 || teams[0].Name != "Chelsea" || teams[1].Name != "Arsenal" {
+		t.Errorf("migrated teams are %+v", teams)
+	}
+	matches := league.Matches()
+	if len(matches) != 2 || !matches[0][0].Played || matches[0][0].HomeGoals != 2 || matches[1][0].Played {
+		t.Errorf("migrated matches are %+v", matches)
+	}
+	if table := league.GetStandings(); table[0].Team.Name != "Chelsea" || table[0].Points != 3 {
+		t.Errorf("migrated table is %+v", table)
+	}
+
+	// The migrated league carries on from week 2 like any other.
+	if err := league.SimulateWeek(); err != nil {
+		t.Fatal(err)
+	}
+	if matches = league.Matches(); !matches[1][0].Played || matches[0][0].HomeGoals != 2 || matches[0][0].AwayGoals != 1 {
+		t.Errorf("simulating the migrated league gave %+v, want week 2 played and week 1 kept", matches)
+	}
+	if next, err := registry.Create("", testTeams(2)); err != nil || next.ID() != 2 {
+		t.Errorf("the next league got ID %v (%v), want 2", next, err)
+	}
+}
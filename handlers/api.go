@@ -2,8 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"league-simulator/models"
 	"league-simulator/services"
@@ -12,26 +17,81 @@ import (
 )
 
 type API struct {
-	Simulator services.LeagueSimulator
+	Leagues   *services.Registry
 	Predictor services.Predictor
 }
 
-func NewAPI(sim services.LeagueSimulator, pred services.Predictor) *API {
+func NewAPI(leagues *services.Registry, pred services.Predictor) *API {
 	return &API{
-		Simulator: sim,
+		Leagues:   leagues,
 		Predictor: pred,
 	}
 }
 
 func (api *API) RegisterRoutes(router *mux.Router) {
+	router.Use(validateRequest)
 	router.HandleFunc("/", api.LandingPage).Methods("GET")
-	router.HandleFunc("/simulate/week", api.SimulateWeek).Methods("POST")
-	router.HandleFunc("/simulate/all", api.SimulateAll).Methods("POST")
-	router.HandleFunc("/standings", api.GetStandings).Methods("GET")
-	router.HandleFunc("/predict", api.PredictRemaining).Methods("GET")
-	router.HandleFunc("/matches", api.Matches).Methods("GET")
-	router.HandleFunc("/match/edit", api.EditMatchResult).Methods("POST")
-	router.HandleFunc("/reset", api.Reset).Methods("POST")
+	router.HandleFunc("/openapi.json", api.OpenAPI).Methods("GET")
+	router.HandleFunc("/docs", api.Docs).Methods("GET")
+	api.registerLeagueRoutes(router, api.GetStandings, api.Matches)
+	api.registerCupRoutes(router)
+	api.registerTournamentRoutes(router)
+	api.registerPyramidRoutes(router)
+
+	// /v1 serves the same routes, but tables and fixtures use the explicit
+	// response types in v1.go instead of the raw models.
+	v1 := router.PathPrefix("/v1").Subrouter()
+	api.registerLeagueRoutes(v1, api.GetStandingsV1, api.MatchesV1)
+	api.registerCupRoutes(v1)
+	api.registerTournamentRoutes(v1)
+	api.registerPyramidRoutes(v1)
+	v1.HandleFunc("/leagues/{id:[0-9]+}/matches/{matchId:[0-9]+}", api.GetMatchV1).Methods("GET")
+}
+
+// registerLeagueRoutes adds the league endpoints to router. The standings
+// and matches handlers differ between the unversioned and /v1 formats.
+func (api *API) registerLeagueRoutes(router *mux.Router, standings, matches http.HandlerFunc) {
+	router.HandleFunc("/leagues", api.ListLeagues).Methods("GET")
+	router.HandleFunc("/leagues", api.CreateLeague).Methods("POST")
+	router.HandleFunc("/events", api.Events).Methods("GET")
+
+	league := router.PathPrefix("/leagues/{id:[0-9]+}").Subrouter()
+	league.HandleFunc("", api.GetLeague).Methods("GET")
+	league.HandleFunc("", api.DeleteLeague).Methods("DELETE")
+	league.HandleFunc("/simulate/week", api.SimulateWeek).Methods("POST")
+	league.HandleFunc("/simulate/all", api.SimulateAll).Methods("POST")
+	league.HandleFunc("/standings", standings).Methods("GET")
+	league.HandleFunc("/predict", api.PredictRemaining).Methods("GET")
+	league.HandleFunc("/predict/matches", api.PredictMatches).Methods("GET")
+	league.HandleFunc("/matches", matches).Methods("GET")
+	league.HandleFunc("/match/edit", api.EditMatchResult).Methods("POST")
+	league.HandleFunc("/match/{matchId:[0-9]+}/events", api.GetMatchEvents).Methods("GET")
+	league.HandleFunc("/reset", api.Reset).Methods("POST")
+	league.HandleFunc("/history", api.GetHistory).Methods("GET")
+	league.HandleFunc("/undo", api.Undo).Methods("POST")
+	league.HandleFunc("/redo", api.Redo).Methods("POST")
+	league.HandleFunc("/ratings", api.GetRatings).Methods("GET")
+	league.HandleFunc("/events", api.Events).Methods("GET")
+	league.HandleFunc("/teams", api.ListTeams).Methods("GET")
+	league.HandleFunc("/teams", api.CreateTeam).Methods("POST")
+	league.HandleFunc("/teams/{teamId:[0-9]+}", api.GetTeam).Methods("GET")
+	league.HandleFunc("/teams/{teamId:[0-9]+}", api.UpdateTeam).Methods("PUT")
+	league.HandleFunc("/teams/{teamId:[0-9]+}", api.DeleteTeam).Methods("DELETE")
+	league.HandleFunc("/teams/{teamId:[0-9]+}/players", api.ListPlayers).Methods("GET")
+	league.HandleFunc("/teams/{teamId:[0-9]+}/players", api.CreatePlayer).Methods("POST")
+	league.HandleFunc("/teams/{teamId:[0-9]+}/players/{playerId:[0-9]+}", api.GetPlayer).Methods("GET")
+	league.HandleFunc("/teams/{teamId:[0-9]+}/players/{playerId:[0-9]+}", api.UpdatePlayer).Methods("PUT")
+	league.HandleFunc("/teams/{teamId:[0-9]+}/players/{playerId:[0-9]+}", api.DeletePlayer).Methods("DELETE")
+	league.HandleFunc("/players/{playerId:[0-9]+}/stats", api.GetPlayerStats).Methods("GET")
+	league.HandleFunc("/stats/top-scorers", api.TopScorers).Methods("GET")
+	league.HandleFunc("/seasons", api.ListSeasons).Methods("GET")
+	league.HandleFunc("/seasons/next", api.NextSeason).Methods("POST")
+	league.HandleFunc("/seasons/{season:[0-9]+}/standings", api.GetSeasonStandings).Methods("GET")
+	league.HandleFunc("/playoff", api.GetPlayoff).Methods("GET")
+	league.HandleFunc("/playoff", api.SetPlayoff).Methods("PUT")
+	league.HandleFunc("/playoff", api.DeletePlayoff).Methods("DELETE")
+	league.HandleFunc("/playoff/simulate/round", api.SimulatePlayoffRound).Methods("POST")
+	league.HandleFunc("/playoff/simulate/all", api.SimulatePlayoffAll).Methods("POST")
 }
 
 func (api *API) LandingPage(w http.ResponseWriter, r *http.Request) {
@@ -39,285 +99,253 @@ func (api *API) LandingPage(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Football League Simulator API"))
 }
 
+// simulateRequest is the optional body accepted by the simulate endpoints.
+type simulateRequest struct {
+	Seed *int64 `json:"seed"`
+}
+
+// requestSeed reads the seed from a simulate request body. An empty body
+// gives nil, which keeps the current seed.
+func requestSeed(r *http.Request) (*int64, error) {
+	var req simulateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	return req.Seed, nil
+}
+
 func (api *API) SimulateWeek(w http.ResponseWriter, r *http.Request) {
-	played := api.Simulator.SimulateWeek()
-	w.Header().Set("Content-Type", "application/json")
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
 
-	if !played {
-		w.WriteHeader(http.StatusGone) // 410: Artık oynanacak maç yok
-		json.NewEncoder(w).Encode(map[string]string{
-			"message": "No more matches left to simulate",
-		})
+	seed, err := requestSeed(r)
+	if err != nil {
+		writeError(w, errInvalidBody)
 		return
 	}
 
+	used, err := sim.SimulateWeekSeeded(seed)
+	if err != nil {
+		writeError(w, err) // 409 once every match has been played
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "One week simulated",
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":     "One week simulated",
+		"seed":        used.League,
+		"season_seed": used.Season,
 	})
 }
 
 func (api *API) SimulateAll(w http.ResponseWriter, r *http.Request) {
-	api.Simulator.SimulateAll()
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	seed, err := requestSeed(r)
+	if err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+
+	used := sim.SimulateAllSeeded(seed)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "All remaining matches simulated",
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":     "All remaining matches simulated",
+		"seed":        used.League,
+		"season_seed": used.Season,
 	})
 }
 
 func (api *API) GetStandings(w http.ResponseWriter, r *http.Request) {
-	standings := api.Simulator.GetStandings()
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	standings := sim.GetStandings()
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(standings)
 }
+
+// maxForecastIterations caps the work a single /predict request can trigger.
+const maxForecastIterations = 100000
+
+// forecastOptions reads the iterations and seed query parameters shared by
+// the prediction endpoints. The ratings come from snapshot so they match the
+// fixtures being predicted.
+func forecastOptions(sim services.LeagueSimulator, snapshot services.LeagueSnapshot, r *http.Request) (services.ForecastOptions, error) {
+	opts := services.ForecastOptions{
+		Iterations:  services.DefaultForecastIterations,
+		Seed:        time.Now().UnixNano(),
+		Engine:      sim.Engine(),
+		Tiebreakers: sim.Tiebreakers(),
+		Ratings:     snapshot.Ratings,
+	}
+	if raw := r.URL.Query().Get("iterations"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxForecastIterations {
+			return opts, fmt.Errorf("%w: iterations must be between 1 and %d", errInvalidParameter, maxForecastIterations)
+		}
+		opts.Iterations = n
+	}
+	if raw := r.URL.Query().Get("seed"); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return opts, fmt.Errorf("%w: seed must be an integer", errInvalidParameter)
+		}
+		opts.Seed = n
+	}
+	return opts, nil
+}
+
 func (api *API) PredictRemaining(w http.ResponseWriter, r *http.Request) {
-	allMatches := api.Simulator.Matches()
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	snapshot := sim.Snapshot()
+	opts, err := forecastOptions(sim, snapshot, r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
 	var flatMatches []models.Match
-	for _, weekMatches := range allMatches {
+	for _, weekMatches := range snapshot.Matches {
 		flatMatches = append(flatMatches, weekMatches...)
 	}
 
-	currentStandings := api.Simulator.StandingsCopy()
+	forecast := api.Predictor.ForecastPositions(flatMatches, snapshot.Standings, opts)
+	predicted := api.Predictor.PredictFinalStandings(flatMatches, snapshot.Standings, opts)
 
-	// Check if at least 4 weeks have been played
-	minGamesPlayed := 4
-	hasEnoughGames := false
-	for _, standing := range currentStandings {
-		if standing.Played >= minGamesPlayed {
-			hasEnoughGames = true
-			break
+	probabilities := make([]map[string]any, 0, len(forecast.Teams))
+	for _, team := range forecast.Teams {
+		positions := make([]float64, len(team.PositionProbabilities))
+		for i, p := range team.PositionProbabilities {
+			positions[i] = round(p*100, 1)
 		}
+		probabilities = append(probabilities, map[string]any{
+			"team_id":                team.Team.ID,
+			"team_name":              team.Team.Name,
+			"probability":            round(team.ChampionProbability*100, 1),
+			"standard_error":         round(team.StandardError*100, 2),
+			"expected_points":        round(team.ExpectedPoints, 1),
+			"position_probabilities": positions,
+		})
 	}
 
-	if !hasEnoughGames {
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusBadRequest)
-		json.NewEncoder(w).Encode(map[string]string{
-			"error": "Predictions are only available from week 4 onwards. Please simulate more matches.",
+	table := make([]map[string]any, 0, len(predicted))
+	for i, standing := range predicted {
+		table = append(table, map[string]any{
+			"position":        i + 1,
+			"team_id":         standing.Team.ID,
+			"team_name":       standing.Team.Name,
+			"played":          standing.Played,
+			"won":             standing.Won,
+			"drawn":           standing.Drawn,
+			"lost":            standing.Lost,
+			"goal_difference": standing.GoalDiff,
+			"points":          standing.Points,
 		})
-		return
 	}
 
-	// Calculate championship probabilities
-	championshipProbabilities := calculateChampionshipProbabilities(flatMatches, currentStandings)
-
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(map[string]any{
-		"championship_probabilities": championshipProbabilities,
-		"message":                    "Championship winning probabilities based on current form",
+		"championship_probabilities": probabilities,
+		"predicted_table":            table,
+		"iterations":                 forecast.Iterations,
+		"seed":                       forecast.Seed,
+		"standard_error":             round(forecast.MaxStandardError*100, 2),
+		"message":                    "Finishing position probabilities from Monte Carlo simulation of the remaining fixtures",
 	}); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		writeError(w, err)
 	}
 }
 
-// calculateChampionshipProbabilities calculates each team's probability of winning the league
-func calculateChampionshipProbabilities(matches []models.Match, standings map[int]*models.Standing) []map[string]any {
-	// Get predicted final standings
-	predictedStandings := make(map[int]*models.Standing)
-	for id, standing := range standings {
-		predictedStandings[id] = &models.Standing{
-			Team:         standing.Team,
-			Played:       standing.Played,
-			Won:          standing.Won,
-			Drawn:        standing.Drawn,
-			Lost:         standing.Lost,
-			GoalsFor:     standing.GoalsFor,
-			GoalsAgainst: standing.GoalsAgainst,
-			GoalDiff:     standing.GoalDiff,
-			Points:       standing.Points,
-		}
+// PredictMatches returns outcome and scoreline probabilities for unplayed
+// fixtures, optionally filtered by week and team ID.
+func (api *API) PredictMatches(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
 	}
 
-	// Simulate remaining matches
-	for _, match := range matches {
-		if !match.Played {
-			homeStanding := predictedStandings[match.Home.ID]
-			awayStanding := predictedStandings[match.Away.ID]
-
-			homePointsPerGame := 0.0
-			awayPointsPerGame := 0.0
-
-			if homeStanding.Played > 0 {
-				homePointsPerGame = float64(homeStanding.Points) / float64(homeStanding.Played)
-			}
-			if awayStanding.Played > 0 {
-				awayPointsPerGame = float64(awayStanding.Points) / float64(awayStanding.Played)
-			}
-
-			formDifference := homePointsPerGame - awayPointsPerGame
-
-			if formDifference > 0.5 {
-				homeStanding.Won++
-				homeStanding.Points += 3
-				awayStanding.Lost++
-			} else if formDifference < -0.5 {
-				awayStanding.Won++
-				awayStanding.Points += 3
-				homeStanding.Lost++
-			} else {
-				homeStanding.Drawn++
-				homeStanding.Points += 1
-				awayStanding.Drawn++
-				awayStanding.Points += 1
-			}
-
-			homeStanding.Played++
-			awayStanding.Played++
-		}
+	snapshot := sim.Snapshot()
+	opts, err := forecastOptions(sim, snapshot, r)
+	if err != nil {
+		writeError(w, err)
+		return
 	}
 
-	// Convert to slice and find max points
-	var allStandings []models.Standing
-	maxPoints := 0
-	for _, standing := range predictedStandings {
-		allStandings = append(allStandings, *standing)
-		if standing.Points > maxPoints {
-			maxPoints = standing.Points
+	week, team := 0, 0
+	if raw := r.URL.Query().Get("week"); raw != "" {
+		if week, err = strconv.Atoi(raw); err != nil || week <= 0 {
+			writeError(w, fmt.Errorf("%w: week must be a positive integer", errInvalidParameter))
+			return
 		}
 	}
-
-	// Calculate probabilities based on points difference from leader
-	var probabilities []map[string]any
-	totalProbability := 0.0
-
-	for _, standing := range allStandings {
-		pointsDiff := maxPoints - standing.Points
-
-		// Apply stricter rules based on week
-		week := 0
-		for _, s := range standings {
-			if s.Played > week {
-				week = s.Played
-			}
+	if raw := r.URL.Query().Get("team"); raw != "" {
+		if team, err = strconv.Atoi(raw); err != nil {
+			writeError(w, fmt.Errorf("%w: team must be a team ID", errInvalidParameter))
+			return
 		}
-
-		var probability float64
-		switch {
-		case week >= 6:
-			// In 6th week, only the leader can win
-			if pointsDiff == 0 {
-				probability = 100.0
-			} else {
-				probability = 0.0
-			}
-		case week >= 5:
-			// After 5th week, >3 points behind can't win
-			if pointsDiff == 0 {
-				probability = 50.0
-			} else if pointsDiff <= 3 {
-				probability = 30.0
-			} else {
-				probability = 0.0
-			}
-		case week >= 4:
-			// After 4th week, >6 points behind can't win
-			if pointsDiff == 0 {
-				probability = 50.0
-			} else if pointsDiff <= 3 {
-				probability = 30.0
-			} else if pointsDiff <= 6 {
-				probability = 15.0
-			} else {
-				probability = 0.0
-			}
-		default:
-			// Default probabilities
-			if pointsDiff == 0 {
-				probability = 50.0
-			} else if pointsDiff <= 3 {
-				probability = 30.0
-			} else if pointsDiff <= 6 {
-				probability = 15.0
-			} else if pointsDiff <= 9 {
-				probability = 4.0
-			} else {
-				probability = 1.0
-			}
-		}
-
-		probabilities = append(probabilities, map[string]any{
-			"team_name":   standing.Team.Name,
-			"probability": probability,
-		})
-		totalProbability += probability
 	}
 
-	// Normalize probabilities to sum to 100%
-	for i := range probabilities {
-		originalProb := probabilities[i]["probability"].(float64)
-		normalizedProb := (originalProb / totalProbability) * 100
-		probabilities[i]["probability"] = round(normalizedProb, 1)
+	// The predictor needs every played match to judge form, so filter its
+	// forecasts rather than the fixtures it is given.
+	var flatMatches []models.Match
+	for _, weekMatches := range snapshot.Matches {
+		flatMatches = append(flatMatches, weekMatches...)
 	}
 
-	return probabilities
-}
-
-// calculateMatchPredictions calculates win percentages for unplayed matches based on current standings
-func calculateMatchPredictions(matches []models.Match, standings map[int]*models.Standing) []map[string]any {
-	var predictions []map[string]any
-
-	for _, match := range matches {
-		if !match.Played {
-			homeStanding := standings[match.Home.ID]
-			awayStanding := standings[match.Away.ID]
-
-			// Calculate points per game (avoid division by zero)
-			homePointsPerGame := 0.0
-			awayPointsPerGame := 0.0
-
-			if homeStanding.Played > 0 {
-				homePointsPerGame = float64(homeStanding.Points) / float64(homeStanding.Played)
-			}
-			if awayStanding.Played > 0 {
-				awayPointsPerGame = float64(awayStanding.Points) / float64(awayStanding.Played)
-			}
-
-			// Calculate strength ratio (no home advantage)
-			homeStrength := homePointsPerGame
-			awayStrength := awayPointsPerGame
-			totalStrength := homeStrength + awayStrength
-
-			// Calculate percentages
-			homeWinPercentage := 0.0
-			awayWinPercentage := 0.0
-			drawPercentage := 15.0 // Base draw percentage
-
-			if totalStrength > 0 {
-				homeWinPercentage = (homeStrength / totalStrength) * 85.0 // 85% for non-draw outcomes
-				awayWinPercentage = (awayStrength / totalStrength) * 85.0
-			} else {
-				// If no games played, assume equal chances
-				homeWinPercentage = 42.5
-				awayWinPercentage = 42.5
-				drawPercentage = 15.0
-			}
-
-			// Ensure percentages add up to 100%
-			total := homeWinPercentage + awayWinPercentage + drawPercentage
-			if total > 0 {
-				homeWinPercentage = (homeWinPercentage / total) * 100
-				awayWinPercentage = (awayWinPercentage / total) * 100
-				drawPercentage = (drawPercentage / total) * 100
-			}
-
-			predictions = append(predictions, map[string]any{
-				"match_id":            match.ID,
-				"home_team":           match.Home.Name,
-				"away_team":           match.Away.Name,
-				"week":                match.Week,
-				"home_win_percentage": round(homeWinPercentage, 1),
-				"away_win_percentage": round(awayWinPercentage, 1),
-				"draw_percentage":     round(drawPercentage, 1),
-				"home_current_points": homeStanding.Points,
-				"away_current_points": awayStanding.Points,
-				"home_played":         homeStanding.Played,
-				"away_played":         awayStanding.Played,
+	predictions := []map[string]any{}
+	for _, forecast := range api.Predictor.PredictMatches(flatMatches, opts) {
+		match := forecast.Match
+		if week != 0 && match.Week != week {
+			continue
+		}
+		if team != 0 && match.Home.ID != team && match.Away.ID != team {
+			continue
+		}
+		scorelines := make([]map[string]any, 0, len(forecast.Scorelines))
+		for _, sl := range forecast.Scorelines {
+			scorelines = append(scorelines, map[string]any{
+				"home_goals":  sl.HomeGoals,
+				"away_goals":  sl.AwayGoals,
+				"probability": round(sl.Probability*100, 1),
 			})
 		}
+		prediction := map[string]any{
+			"match_id":            forecast.Match.ID,
+			"home_team":           forecast.Match.Home.Name,
+			"away_team":           forecast.Match.Away.Name,
+			"week":                forecast.Match.Week,
+			"home_win_percentage": round(forecast.HomeWin*100, 1),
+			"draw_percentage":     round(forecast.Draw*100, 1),
+			"away_win_percentage": round(forecast.AwayWin*100, 1),
+			"scorelines":          scorelines,
+		}
+		if len(forecast.Scorelines) > 0 {
+			best := forecast.Scorelines[0]
+			prediction["most_likely_score"] = fmt.Sprintf("%d-%d", best.HomeGoals, best.AwayGoals)
+		}
+		predictions = append(predictions, prediction)
 	}
 
-	return predictions
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(map[string]any{
+		"predictions": predictions,
+	}); err != nil {
+		writeError(w, err)
+	}
 }
 
 // round rounds a float64 to specified decimal places
@@ -326,41 +354,134 @@ func round(val float64, precision int) float64 {
 	return math.Round(val*ratio) / ratio
 }
 
+// legacyMatch is a fixture in the shape the unversioned /matches route has
+// always served. Timelines are left to /v1 and the match events endpoint.
+type legacyMatch struct {
+	ID        int
+	Home      models.Team
+	Away      models.Team
+	HomeGoals int
+	AwayGoals int
+	Played    bool
+	Week      int
+}
+
+func newLegacyMatches(weekMatches []models.Match) []legacyMatch {
+	matches := make([]legacyMatch, 0, len(weekMatches))
+	for _, match := range weekMatches {
+		matches = append(matches, legacyMatch{
+			ID:        match.ID,
+			Home:      match.Home,
+			Away:      match.Away,
+			HomeGoals: match.HomeGoals,
+			AwayGoals: match.AwayGoals,
+			Played:    match.Played,
+			Week:      match.Week,
+		})
+	}
+	return matches
+}
+
 func (api *API) Matches(w http.ResponseWriter, r *http.Request) {
-	allMatches := api.Simulator.Matches()
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	// Matches and teams must come from the same snapshot to name the bye correctly
+	snapshot := sim.Snapshot()
+	weeks := make([]map[string]any, 0, len(snapshot.Matches))
+	for i, weekMatches := range snapshot.Matches {
+		week := map[string]any{
+			"week":    i + 1,
+			"matches": newLegacyMatches(weekMatches),
+		}
+		if resting := services.RestingTeams(snapshot.Teams, weekMatches); len(resting) > 0 {
+			week["bye"] = resting[0]
+		}
+		weeks = append(weeks, week)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(allMatches); err != nil {
-		http.Error(w, "Failed to encode matches", http.StatusInternalServerError)
+	if err := json.NewEncoder(w).Encode(weeks); err != nil {
+		writeError(w, err)
 	}
 }
+
+// EditMatchResult sets a match result. Sending "played": false clears the
+// result instead, returning the match to the fixture list.
 func (api *API) EditMatchResult(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
 	var result struct {
-		MatchID   int `json:"match_id"`
-		HomeGoals int `json:"home_goals"`
-		AwayGoals int `json:"away_goals"`
+		MatchID   int   `json:"match_id"`
+		HomeGoals *int  `json:"home_goals"`
+		AwayGoals *int  `json:"away_goals"`
+		Played    *bool `json:"played"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&result); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		writeError(w, errInvalidBody)
 		return
 	}
 
-	err := api.Simulator.EditMatchResult(result.MatchID, result.HomeGoals, result.AwayGoals)
+	var err error
+	message := "Match result updated"
+	if result.Played != nil && !*result.Played {
+		err = sim.ClearMatchResult(result.MatchID)
+		message = "Match result cleared"
+	} else {
+		// The schema cannot express "required unless played is false", so
+		// a missing score is caught here rather than recorded as 0-0.
+		var missing []fieldError
+		if result.HomeGoals == nil {
+			missing = append(missing, fieldError{Field: "home_goals", Message: "is required"})
+		}
+		if result.AwayGoals == nil {
+			missing = append(missing, fieldError{Field: "away_goals", Message: "is required"})
+		}
+		if len(missing) > 0 {
+			writeErrorDetails(w, errValidation, missing)
+			return
+		}
+		err = sim.EditMatchResult(result.MatchID, *result.HomeGoals, *result.AwayGoals)
+	}
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	api.Simulator.RecalculateStandings()
+	match, err := sim.GetMatchByID(result.MatchID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{"message": "Match result updated"})
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":    message,
+		"match_id":   match.ID,
+		"home_goals": match.HomeGoals,
+		"away_goals": match.AwayGoals,
+		"played":     match.Played,
+	})
 }
 
 func (api *API) Reset(w http.ResponseWriter, r *http.Request) {
-	api.Simulator.Reset()
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	sim.Reset()
+	seeds := sim.Seeds()
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "League has been reset",
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":     "League has been reset",
+		"seed":        seeds.League,
+		"season_seed": seeds.Season,
 	})
 }
@@ -0,0 +1,422 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	leagues := services.NewRegistry(nil, services.WithSeed(1))
+	teams := []models.Team{
+		{ID: 1, Name: "Chelsea", Strength: 8},
+		{ID: 2, Name: "Arsenal", Strength: 7},
+		{ID: 3, Name: "Manchester City", Strength: 9},
+		{ID: 4, Name: "Liverpool", Strength: 8},
+		{ID: 5, Name: "Everton", Strength: 5},
+		{ID: 6, Name: "Fulham", Strength: 4},
+	}
+	if _, err := leagues.Create("Premier League", teams); err != nil {
+		t.Fatal(err)
+	}
+	router := mux.NewRouter()
+	NewAPI(leagues, services.NewPredictor()).RegisterRoutes(router)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+	return server
+}
+
+// call sends a request and decodes a successful JSON response into out, if
+// given. It returns the status code.
+func call(t *testing.T, method, url string, body any, out any) int {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&reader).Encode(body)
+	}
+	req, err := http.NewRequest(method, url, &reader)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Error(err)
+		return 0
+	}
+	defer resp.Body.Close()
+	if out != nil && resp.StatusCode/100 == 2 {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Errorf("%s %s: %v", method, url, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// checkTable verifies that a /v1 table adds up: every result has a winner
+// and a loser or two drawing teams, and points follow from the results.
+func checkTable(table []StandingResponse) error {
+	var played, won, drawn, lost, goalsFor, goalsAgainst int
+	for _, row := range table {
+		if row.Points != 3*row.Won+row.Drawn || row.Played != row.Won+row.Drawn+row.Lost {
+			return fmt.Errorf("inconsistent row for %s: %+v", row.Team.Name, row)
+		}
+		played += row.Played
+		won += row.Won
+		drawn += row.Drawn
+		lost += row.Lost
+		goalsFor += row.GoalsFor
+		goalsAgainst += row.GoalsAgainst
+	}
+	if played%2 != 0 || won != lost || drawn%2 != 0 || goalsFor != goalsAgainst {
+		return fmt.Errorf("table does not add up: played %d, won %d, drawn %d, lost %d, goals %d-%d",
+			played, won, drawn, lost, goalsFor, goalsAgainst)
+	}
+	return nil
+}
+
+func TestConcurrentRequests(t *testing.T) {
+	server := newTestServer(t)
+	league := server.URL + "/leagues/1"
+	v1 := server.URL + "/v1/leagues/1"
+
+	var wg sync.WaitGroup
+	run := func(n int, op func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				op(i)
+			}
+		}()
+	}
+	expect := func(status int, allowed ...int) {
+		for _, code := range allowed {
+			if status == code {
+				return
+			}
+		}
+		t.Errorf("unexpected status %d", status)
+	}
+
+	run(30, func(int) {
+		expect(call(t, "POST", league+"/simulate/week", nil, nil), http.StatusOK, http.StatusConflict)
+	})
+	run(10, func(int) { expect(call(t, "POST", league+"/simulate/all", nil, nil), http.StatusOK) })
+	run(5, func(int) { expect(call(t, "POST", league+"/reset", nil, nil), http.StatusOK) })
+	run(30, func(i int) {
+		edit := map[string]int{"match_id": i%30 + 1, "home_goals": i % 4, "away_goals": i % 3}
+		expect(call(t, "POST", league+"/match/edit", edit, nil), http.StatusOK)
+	})
+	run(30, func(int) {
+		var table []StandingResponse
+		expect(call(t, "GET", v1+"/standings", nil, &table), http.StatusOK)
+		if err := checkTable(table); err != nil {
+			t.Error(err)
+		}
+	})
+	run(30, func(int) {
+		expect(call(t, "GET", league+"/standings", nil, nil), http.StatusOK)
+		expect(call(t, "GET", league+"/matches", nil, nil), http.StatusOK)
+		expect(call(t, "GET", v1+"/matches", nil, nil), http.StatusOK)
+		expect(call(t, "GET", league, nil, nil), http.StatusOK)
+	})
+	wg.Wait()
+
+	var table []StandingResponse
+	var weeks []WeekResponse
+	call(t, "GET", v1+"/standings", nil, &table)
+	call(t, "GET", v1+"/matches", nil, &weeks)
+	if err := checkTable(table); err != nil {
+		t.Error(err)
+	}
+	matches, goals := 0, 0
+	for _, week := range weeks {
+		for _, match := range week.Matches {
+			if match.Status == MatchPlayed {
+				matches++
+				goals += *match.HomeGoals + *match.AwayGoals
+			}
+		}
+	}
+	played, goalsFor := 0, 0
+	for _, row := range table {
+		played += row.Played
+		goalsFor += row.GoalsFor
+	}
+	if played != 2*matches || goalsFor != goals {
+		t.Errorf("table counts %d appearances and %d goals, matches have %d and %d", played, goalsFor, 2*matches, goals)
+	}
+
+	var history struct {
+		Events []struct {
+			Seq     int    `json:"seq"`
+			Type    string `json:"type"`
+			Week    int    `json:"week"`
+			Results []struct {
+				MatchID int `json:"match_id"`
+			} `json:"results"`
+		} `json:"events"`
+	}
+	call(t, "GET", league+"/history", nil, &history)
+	weeksPlayed := make(map[int]bool)
+	matchesPlayed := make(map[int]bool)
+	for _, event := range history.Events {
+		switch services.EventType(event.Type) {
+		case services.EventReset:
+			weeksPlayed = make(map[int]bool)
+			matchesPlayed = make(map[int]bool)
+		case services.EventWeekSimulated:
+			if weeksPlayed[event.Week] {
+				t.Errorf("week %d simulated twice (event %d)", event.Week, event.Seq)
+			}
+			weeksPlayed[event.Week] = true
+			for _, result := range event.Results {
+				if matchesPlayed[result.MatchID] {
+					t.Errorf("match %d simulated twice (event %d)", result.MatchID, event.Seq)
+				}
+				matchesPlayed[result.MatchID] = true
+			}
+		}
+	}
+}
+
+func TestEditMatchResult(t *testing.T) {
+	server := newTestServer(t)
+	league := server.URL + "/leagues/1"
+
+	type editResponse struct {
+		MatchID   int  `json:"match_id"`
+		HomeGoals int  `json:"home_goals"`
+		AwayGoals int  `json:"away_goals"`
+		Played    bool `json:"played"`
+	}
+	table := func() map[int]StandingResponse {
+		var rows []StandingResponse
+		call(t, "GET", server.URL+"/v1/leagues/1/standings", nil, &rows)
+		byTeam := make(map[int]StandingResponse, len(rows))
+		for _, row := range rows {
+			byTeam[row.Team.ID] = row
+		}
+		return byTeam
+	}
+	var weeks []WeekResponse
+	call(t, "GET", server.URL+"/v1/leagues/1/matches", nil, &weeks)
+	first := weeks[0].Matches[0]
+	home, away := first.Home.ID, first.Away.ID
+
+	tests := []struct {
+		name       string
+		body       any
+		status     int
+		want       *editResponse
+		homePoints int
+		awayPoints int
+		played     int
+	}{
+		{"edit an unplayed match", map[string]any{"match_id": first.ID, "home_goals": 2, "away_goals": 1}, http.StatusOK,
+			&editResponse{first.ID, 2, 1, true}, 3, 0, 1},
+		{"edit it again without double counting", map[string]any{"match_id": first.ID, "home_goals": 0, "away_goals": 3}, http.StatusOK,
+			&editResponse{first.ID, 0, 3, true}, 0, 3, 1},
+		{"missing goals are not a 0-0", map[string]any{"match_id": first.ID}, http.StatusUnprocessableEntity,
+			nil, 0, 3, 1},
+		{"missing away goals", map[string]any{"match_id": first.ID, "home_goals": 1}, http.StatusUnprocessableEntity,
+			nil, 0, 3, 1},
+		{"unknown match", map[string]any{"match_id": 999, "home_goals": 1, "away_goals": 1}, http.StatusNotFound,
+			nil, 0, 3, 1},
+		{"clear without goals", map[string]any{"match_id": first.ID, "played": false}, http.StatusOK,
+			&editResponse{first.ID, 0, 0, false}, 0, 0, 0},
+		{"clear an unplayed match", map[string]any{"match_id": first.ID, "played": false}, http.StatusOK,
+			&editResponse{first.ID, 0, 0, false}, 0, 0, 0},
+		{"clear an unknown match", map[string]any{"match_id": 999, "played": false}, http.StatusNotFound,
+			nil, 0, 0, 0},
+		{"played true still needs goals", map[string]any{"match_id": first.ID, "played": true}, http.StatusUnprocessableEntity,
+			nil, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		var got editResponse
+		if status := call(t, "POST", league+"/match/edit", tt.body, &got); status != tt.status {
+			t.Fatalf("%s: status %d, want %d", tt.name, status, tt.status)
+		}
+		if tt.want != nil && got != *tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, *tt.want)
+		}
+		rows := table()
+		if rows[home].Points != tt.homePoints || rows[away].Points != tt.awayPoints {
+			t.Errorf("%s: points %d and %d, want %d and %d", tt.name, rows[home].Points, rows[away].Points, tt.homePoints, tt.awayPoints)
+		}
+		if rows[home].Played != tt.played || rows[away].Played != tt.played {
+			t.Errorf("%s: played %d and %d, want %d", tt.name, rows[home].Played, rows[away].Played, tt.played)
+		}
+	}
+}
+
+func TestUnversionedMatchesKeepTheirShape(t *testing.T) {
+	server := newTestServer(t)
+	call(t, "POST", server.URL+"/leagues/1/simulate/week", nil, nil)
+
+	var weeks []struct {
+		Matches []map[string]json.RawMessage `json:"matches"`
+	}
+	call(t, "GET", server.URL+"/leagues/1/matches", nil, &weeks)
+	want := []string{"ID", "Home", "Away", "HomeGoals", "AwayGoals", "Played", "Week"}
+	for _, week := range weeks {
+		for _, match := range week.Matches {
+			if len(match) != len(want) {
+				t.Fatalf("match has fields %v, want %v", match, want)
+			}
+			for _, field := range want {
+				if _, ok := match[field]; !ok {
+					t.Fatalf("match is missing %s", field)
+				}
+			}
+		}
+	}
+}
+
+func TestConcurrentPlayerUpdatesKeepEachField(t *testing.T) {
+	server := newTestServer(t)
+	squad := server.URL + "/leagues/1/teams/1/players"
+
+	var player struct {
+		ID     int    `json:"id"`
+		TeamID int    `json:"team_id"`
+		Name   string `json:"name"`
+		Rating int    `json:"rating"`
+	}
+	if status := call(t, "POST", squad, map[string]any{"name": "Striker", "position": "FW", "rating": 60}, &player); status != http.StatusCreated {
+		t.Fatalf("creating a player: status %d", status)
+	}
+	url := fmt.Sprintf("%s/%d", squad, player.ID)
+
+	// Each round resets the player, then renames it and changes its rating at
+	// once. Neither update may undo the other.
+	var wg sync.WaitGroup
+	for i := 1; i <= 50; i++ {
+		call(t, "PUT", url, map[string]any{"name": "Striker", "rating": 30}, nil)
+		name, rating := fmt.Sprintf("Striker %d", i), 40+i
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if status := call(t, "PUT", url, map[string]any{"rating": rating}, nil); status != http.StatusOK {
+				t.Errorf("rating update: status %d", status)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if status := call(t, "PUT", url, map[string]any{"name": name}, nil); status != http.StatusOK {
+				t.Errorf("name update: status %d", status)
+			}
+		}()
+		wg.Wait()
+
+		call(t, "GET", url, nil, &player)
+		if player.Name != name || player.Rating != rating {
+			t.Fatalf("round %d: an update was lost: %+v", i, player)
+		}
+	}
+
+	// A rename racing a transfer must not move the player back.
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		call(t, "PUT", url, map[string]any{"team_id": 2}, nil)
+	}()
+	go func() {
+		defer wg.Done()
+		call(t, "PUT", url, map[string]any{"name": "Transferred"}, nil)
+	}()
+	wg.Wait()
+	moved := fmt.Sprintf("%s/leagues/1/teams/2/players/%d", server.URL, player.ID)
+	if status := call(t, "GET", moved, nil, &player); status != http.StatusOK || player.TeamID != 2 {
+		t.Errorf("after the transfer the player is %+v (status %d), want team 2", player, status)
+	}
+}
+
+func TestCupAliasesActOnTheNewestCup(t *testing.T) {
+	server := newTestServer(t)
+	if status := call(t, "GET", server.URL+"/cup/bracket", nil, nil); status != http.StatusNotFound {
+		t.Fatalf("bracket without cups returned %d, want 404", status)
+	}
+
+	teams := []map[string]any{
+		{"name": "Chelsea", "strength": 8}, {"name": "Arsenal", "strength": 7},
+		{"name": "Everton", "strength": 5}, {"name": "Fulham", "strength": 4},
+	}
+	for _, name := range []string{"League Cup", "FA Cup"} {
+		if status := call(t, "POST", server.URL+"/cups", map[string]any{"name": name, "teams": teams}, nil); status != http.StatusCreated {
+			t.Fatalf("creating %s returned %d", name, status)
+		}
+	}
+
+	var round struct {
+		Round struct {
+			Number int `json:"number"`
+		} `json:"round"`
+	}
+	if status := call(t, "POST", server.URL+"/cup/simulate/round", nil, &round); status != http.StatusOK || round.Round.Number != 1 {
+		t.Fatalf("simulating the newest cup returned %d and round %d", status, round.Round.Number)
+	}
+	var bracket struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	call(t, "GET", server.URL+"/v1/cup/bracket", nil, &bracket)
+	if bracket.ID != 2 || bracket.Name != "FA Cup" {
+		t.Errorf("/cup/bracket showed cup %d %q, want 2 \"FA Cup\"", bracket.ID, bracket.Name)
+	}
+	var first map[string]any
+	call(t, "GET", server.URL+"/cups/1", nil, &first)
+	if first["next_round"] != float64(1) {
+		t.Errorf("the older cup moved on to round %v", first["next_round"])
+	}
+}
+
+func TestPyramidAliasShowsTheNewestPyramid(t *testing.T) {
+	server := newTestServer(t)
+	if status := call(t, "GET", server.URL+"/pyramid", nil, nil); status != http.StatusNotFound {
+		t.Fatalf("pyramid without pyramids returned %d, want 404", status)
+	}
+
+	division := func(name string, promotion, relegation int, first int) map[string]any {
+		teams := make([]map[string]any, 4)
+		for i := range teams {
+			teams[i] = map[string]any{"name": fmt.Sprintf("Team %d", first+i), "strength": 5}
+		}
+		return map[string]any{"name": name, "promotion": promotion, "relegation": relegation, "teams": teams}
+	}
+	for _, name := range []string{"Scotland", "England"} {
+		body := map[string]any{"name": name, "divisions": []map[string]any{division("Top", 0, 1, 1), division("Second", 1, 0, 5)}}
+		if status := call(t, "POST", server.URL+"/pyramids", body, nil); status != http.StatusCreated {
+			t.Fatalf("creating %s returned %d", name, status)
+		}
+	}
+
+	var pyramid struct {
+		ID        int    `json:"id"`
+		Name      string `json:"name"`
+		Divisions []struct {
+			Table []struct {
+				Zone string `json:"zone"`
+			} `json:"table"`
+		} `json:"divisions"`
+	}
+	call(t, "GET", server.URL+"/pyramid", nil, &pyramid)
+	if pyramid.ID != 2 || pyramid.Name != "England" || len(pyramid.Divisions) != 2 {
+		t.Fatalf("/pyramid showed %+v, want pyramid 2 \"England\" with 2 divisions", pyramid)
+	}
+	if zone := pyramid.Divisions[0].Table[3].Zone; zone != "relegation" {
+		t.Errorf("bottom of the top division is in zone %q, want relegation", zone)
+	}
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// registerCupRoutes adds the knockout cup endpoints to router. They are the
+// same in every API version.
+func (api *API) registerCupRoutes(router *mux.Router) {
+	router.HandleFunc("/cups", api.ListCups).Methods("GET")
+	router.HandleFunc("/cups", api.CreateCup).Methods("POST")
+
+	cup := router.PathPrefix("/cups/{id:[0-9]+}").Subrouter()
+	cup.HandleFunc("", api.GetCup).Methods("GET")
+	cup.HandleFunc("", api.DeleteCup).Methods("DELETE")
+	cup.HandleFunc("/bracket", api.GetBracket).Methods("GET")
+	cup.HandleFunc("/simulate/round", api.SimulateCupRound).Methods("POST")
+
+	// /cup is the newest cup, for clients that run one cup at a time.
+	router.HandleFunc("/cup/bracket", api.GetBracket).Methods("GET")
+	router.HandleFunc("/cup/simulate/round", api.SimulateCupRound).Methods("POST")
+}
+
+// cup resolves the {id} route variable to a cup, or to the newest cup on
+// routes without one, writing a 404 when it does not exist.
+func (api *API) cup(w http.ResponseWriter, r *http.Request) (*services.Cup, bool) {
+	raw, ok := mux.Vars(r)["id"]
+	if !ok {
+		cups := api.Leagues.ListCups()
+		if len(cups) == 0 {
+			writeError(w, services.ErrCupNotFound)
+			return nil, false
+		}
+		return cups[len(cups)-1], true
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid cup ID", errInvalidParameter))
+		return nil, false
+	}
+	c, err := api.Leagues.GetCup(id)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	return c, true
+}
+
+// cupSummary is the JSON representation of a cup used by /cups. next_round
+// is null once the final has been played.
+func cupSummary(c *services.Cup) map[string]any {
+	teams := make([]map[string]any, 0, len(c.Teams()))
+	for _, team := range c.Teams() {
+		teams = append(teams, teamJSON(team))
+	}
+	bracket := c.Bracket()
+	var nextRound *int
+	for _, round := range bracket {
+		if !round.Decided() {
+			nextRound = &round.Number
+			break
+		}
+	}
+	return map[string]any{
+		"id":         c.ID(),
+		"name":       c.Name(),
+		"teams":      teams,
+		"draw":       c.Draw(),
+		"legs":       c.Legs(),
+		"seed":       c.Seed(),
+		"engine":     services.EngineName(c.Engine()),
+		"rounds":     len(bracket),
+		"next_round": nextRound,
+		"champion":   championJSON(c),
+	}
+}
+
+func championJSON(c *services.Cup) any {
+	if champion, ok := c.Champion(); ok {
+		return newTeamRef(champion)
+	}
+	return nil
+}
+
+// optionalTeamRef is nil for a slot still waiting on an earlier tie.
+func optionalTeamRef(team models.Team) *TeamRef {
+	if team.ID == 0 {
+		return nil
+	}
+	ref := newTeamRef(team)
+	return &ref
+}
+
+func scoreJSON(score *models.Score) *ScoreResponse {
+	if score == nil {
+		return nil
+	}
+	return &ScoreResponse{HomeGoals: score.HomeGoals, AwayGoals: score.AwayGoals}
+}
+
+// Tie statuses.
+const (
+	TiePending   = "pending"
+	TieScheduled = "scheduled"
+	TieBye       = "bye"
+	TieDecided   = "decided"
+)
+
+// tieJSON describes a tie and its place in the bracket: previous_tie_ids are
+// the ties whose winners meet here and next_tie_id is where the winner goes.
+func tieJSON(bracket []services.CupRound, r, i int) map[string]any {
+	tie := bracket[r].Ties[i]
+	legs := make([]MatchResponse, 0, len(tie.Legs))
+	for _, leg := range tie.Legs {
+		legs = append(legs, newMatchResponse(leg))
+	}
+
+	status := TieScheduled
+	switch {
+	case tie.Bye:
+		status = TieBye
+	case tie.WinnerID != 0:
+		status = TieDecided
+	case tie.Home.ID == 0 || tie.Away.ID == 0:
+		status = TiePending
+	}
+
+	var aggregate *ScoreResponse
+	if len(tie.Legs) > 0 {
+		home, away := tie.Aggregate()
+		aggregate = &ScoreResponse{HomeGoals: home, AwayGoals: away}
+	}
+	var winner *TeamRef
+	if tie.WinnerID != 0 {
+		if tie.WinnerID == tie.Away.ID {
+			winner = optionalTeamRef(tie.Away)
+		} else {
+			winner = optionalTeamRef(tie.Home)
+		}
+	}
+	previous := []int{}
+	if r > 0 {
+		previous = append(previous, bracket[r-1].Ties[2*i].ID, bracket[r-1].Ties[2*i+1].ID)
+	}
+	var next *int
+	if r+1 < len(bracket) {
+		next = &bracket[r+1].Ties[i/2].ID
+	}
+
+	return map[string]any{
+		"id":               tie.ID,
+		"round":            tie.Round,
+		"status":           status,
+		"home":             optionalTeamRef(tie.Home),
+		"away":             optionalTeamRef(tie.Away),
+		"legs":             legs,
+		"aggregate":        aggregate,
+		"extra_time":       scoreJSON(tie.ExtraTime),
+		"penalties":        scoreJSON(tie.Penalties),
+		"winner":           winner,
+		"previous_tie_ids": previous,
+		"next_tie_id":      next,
+	}
+}
+
+func roundJSON(bracket []services.CupRound, r int) map[string]any {
+	round := bracket[r]
+	ties := make([]map[string]any, 0, len(round.Ties))
+	for i := range round.Ties {
+		ties = append(ties, tieJSON(bracket, r, i))
+	}
+	return map[string]any{
+		"number": round.Number,
+		"name":   round.Name,
+		"ties":   ties,
+	}
+}
+
+func bracketJSON(bracket []services.CupRound) []map[string]any {
+	rounds := make([]map[string]any, 0, len(bracket))
+	for i := range bracket {
+		rounds = append(rounds, roundJSON(bracket, i))
+	}
+	return rounds
+}
+
+func (api *API) ListCups(w http.ResponseWriter, r *http.Request) {
+	cups := []map[string]any{}
+	for _, c := range api.Leagues.ListCups() {
+		cups = append(cups, cupSummary(c))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cups)
+}
+
+// CreateCup draws a new cup. With a seeded draw the teams are listed in seed
+// order.
+func (api *API) CreateCup(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name   string        `json:"name"`
+		Seed   *int64        `json:"seed"`
+		Teams  []models.Team `json:"teams"`
+		Draw   string        `json:"draw"`
+		Legs   int           `json:"legs"`
+		Engine string        `json:"engine"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+
+	opts := services.CupOptions{Draw: req.Draw, Legs: req.Legs, Seed: req.Seed}
+	if req.Engine != "" {
+		engine, err := services.ParseEngine(req.Engine)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts.Engine = engine
+	}
+	c, err := api.Leagues.CreateCup(req.Name, req.Teams, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(cupSummary(c))
+}
+
+func (api *API) GetCup(w http.ResponseWriter, r *http.Request) {
+	c, ok := api.cup(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cupSummary(c))
+}
+
+func (api *API) DeleteCup(w http.ResponseWriter, r *http.Request) {
+	c, ok := api.cup(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.Leagues.DeleteCup(c.ID()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Cup has been deleted"})
+}
+
+// GetBracket returns every round of the cup, first round first, with ties
+// linked to the ties feeding them and the tie their winner goes to.
+func (api *API) GetBracket(w http.ResponseWriter, r *http.Request) {
+	c, ok := api.cup(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"id":       c.ID(),
+		"name":     c.Name(),
+		"rounds":   bracketJSON(c.Bracket()),
+		"champion": championJSON(c),
+	})
+}
+
+// SimulateCupRound plays every tie of the next round and returns it.
+func (api *API) SimulateCupRound(w http.ResponseWriter, r *http.Request) {
+	c, ok := api.cup(w, r)
+	if !ok {
+		return
+	}
+
+	played, err := c.SimulateRound()
+	if err != nil {
+		writeError(w, err) // 409 once the final has been played
+		return
+	}
+	bracket := c.Bracket()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":  fmt.Sprintf("%s simulated", played.Name),
+		"round":    roundJSON(bracket, played.Number-1),
+		"champion": championJSON(c),
+	})
+}
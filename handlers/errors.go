@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"league-simulator/services"
+)
+
+// Error is the body of every error response.
+type Error struct {
+	// Code is a stable, machine-readable identifier such as "match_not_found".
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// Errors raised by the handlers themselves rather than the services.
+var (
+	errInvalidBody      = errors.New("invalid request body")
+	errInvalidParameter = errors.New("invalid parameter")
+	errValidation       = errors.New("request body failed validation")
+)
+
+// errorStatuses maps sentinel errors onto HTTP statuses and error codes.
+// Anything not listed is reported as a 500.
+var errorStatuses = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{services.ErrLeagueNotFound, http.StatusNotFound, "league_not_found"},
+	{services.ErrTeamNotFound, http.StatusNotFound, "team_not_found"},
+	{services.ErrMatchNotFound, http.StatusNotFound, "match_not_found"},
+	{services.ErrPlayerNotFound, http.StatusNotFound, "player_not_found"},
+	{services.ErrCupNotFound, http.StatusNotFound, "cup_not_found"},
+	{services.ErrTournamentNotFound, http.StatusNotFound, "tournament_not_found"},
+	{services.ErrPyramidNotFound, http.StatusNotFound, "pyramid_not_found"},
+	{services.ErrSeasonNotFound, http.StatusNotFound, "season_not_found"},
+	{services.ErrPlayoffNotFound, http.StatusNotFound, "playoff_not_found"},
+	{services.ErrInvalidTeams, http.StatusBadRequest, "invalid_teams"},
+	{services.ErrInvalidScore, http.StatusBadRequest, "invalid_score"},
+	{services.ErrInvalidPlayer, http.StatusBadRequest, "invalid_player"},
+	{services.ErrInvalidCup, http.StatusBadRequest, "invalid_cup"},
+	{services.ErrInvalidTournament, http.StatusBadRequest, "invalid_tournament"},
+	{services.ErrInvalidPyramid, http.StatusBadRequest, "invalid_pyramid"},
+	{services.ErrInvalidPlayoff, http.StatusBadRequest, "invalid_playoff"},
+	{services.ErrUnknownTiebreaker, http.StatusBadRequest, "unknown_tiebreaker"},
+	{services.ErrUnknownEngine, http.StatusBadRequest, "unknown_engine"},
+	{services.ErrSeasonStarted, http.StatusConflict, "season_started"},
+	{services.ErrSeasonFinished, http.StatusConflict, "season_finished"},
+	{services.ErrSeasonInProgress, http.StatusConflict, "season_in_progress"},
+	{services.ErrNothingToUndo, http.StatusConflict, "nothing_to_undo"},
+	{services.ErrNothingToRedo, http.StatusConflict, "nothing_to_redo"},
+	{services.ErrCupFinished, http.StatusConflict, "cup_finished"},
+	{services.ErrTournamentFinished, http.StatusConflict, "tournament_finished"},
+	{services.ErrPlayoffFinished, http.StatusConflict, "playoff_finished"},
+	{errInvalidBody, http.StatusBadRequest, "invalid_body"},
+	{errInvalidParameter, http.StatusBadRequest, "invalid_parameter"},
+	{errValidation, http.StatusUnprocessableEntity, "validation_failed"},
+}
+
+// writeError answers with the status and code mapped to err.
+func writeError(w http.ResponseWriter, err error) {
+	writeErrorDetails(w, err, nil)
+}
+
+// writeErrorDetails is writeError with extra context, such as the failing
+// fields of a validation error.
+func writeErrorDetails(w http.ResponseWriter, err error, details any) {
+	status, code := http.StatusInternalServerError, "internal_error"
+	for _, mapping := range errorStatuses {
+		if errors.Is(err, mapping.err) {
+			status, code = mapping.status, mapping.code
+			break
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Error{Code: code, Message: err.Error(), Details: details})
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// heartbeatInterval keeps idle streams open through proxies that close
+// silent connections.
+const heartbeatInterval = 15 * time.Second
+
+var errStreamingUnsupported = errors.New("streaming unsupported")
+
+// feedEventJSON is the data line of a server-sent event. Matches and tables
+// use the /v1 response types.
+func feedEventJSON(event services.FeedEvent) map[string]any {
+	item := map[string]any{
+		"league_id": event.LeagueID,
+		"time":      event.Time,
+	}
+	switch event.Type {
+	case services.FeedMatchPlayed, services.FeedMatchEdited:
+		item["match"] = newMatchResponse(*event.Match)
+	case services.FeedWeekCompleted:
+		results := make([]map[string]any, 0, len(event.Results))
+		for _, result := range event.Results {
+			results = append(results, map[string]any{
+				"match_id":   result.MatchID,
+				"home_goals": result.HomeGoals,
+				"away_goals": result.AwayGoals,
+			})
+		}
+		item["week"] = event.Week
+		item["results"] = results
+	case services.FeedStandingsChanged:
+		item["standings"] = newStandingResponses(event.Table, event.Matches)
+	}
+	return item
+}
+
+// resyncJSON is the data line of a Resync event: the current table of the
+// league, or of every league on the all-leagues stream, for the client to
+// reload from in place of the events it missed.
+func (api *API) resyncJSON(event services.FeedEvent) map[string]any {
+	sims := api.Leagues.List()
+	if event.LeagueID != 0 {
+		sims = nil
+		if sim, err := api.Leagues.Get(event.LeagueID); err == nil {
+			sims = append(sims, sim)
+		}
+	}
+	leagues := make([]map[string]any, 0, len(sims))
+	for _, sim := range sims {
+		snapshot := sim.Snapshot()
+		leagues = append(leagues, map[string]any{
+			"league_id":    sim.ID(),
+			"current_week": snapshot.CurrentWeek,
+			"standings":    newStandingResponses(snapshot.Table, snapshot.Matches),
+		})
+	}
+	return map[string]any{
+		"league_id": event.LeagueID,
+		"time":      event.Time,
+		"leagues":   leagues,
+	}
+}
+
+// lastEventID reads the ID a client resumes from, or -1 for a fresh
+// subscription. Browsers send the Last-Event-ID header when reconnecting;
+// the last_event_id query parameter lets a first connection replay the
+// backlog.
+func lastEventID(r *http.Request) (int64, error) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("last_event_id")
+	}
+	if raw == "" {
+		return -1, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("%w: last event ID must be a non-negative integer", errInvalidParameter)
+	}
+	return id, nil
+}
+
+// Events streams league events as server-sent events: every league under
+// /events, a single one under /leagues/{id}/events. Without a last event ID
+// the stream starts with new events only; with one older than the backlog,
+// or after falling behind, the client gets a Resync with the current tables.
+func (api *API) Events(w http.ResponseWriter, r *http.Request) {
+	leagueID := 0
+	if _, ok := mux.Vars(r)["id"]; ok {
+		sim, ok := api.league(w, r)
+		if !ok {
+			return
+		}
+		leagueID = sim.ID()
+	}
+
+	lastID, err := lastEventID(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errStreamingUnsupported)
+		return
+	}
+
+	missed, events, cancel := api.Leagues.Feed().Subscribe(leagueID, lastID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	for _, event := range missed {
+		if err := api.writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := api.writeEvent(w, event); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// writeEvent writes one event in the text/event-stream format. A
+// StandingsChanged event without a table, as replayed from the backlog,
+// carries the league's current table.
+func (api *API) writeEvent(w http.ResponseWriter, event services.FeedEvent) error {
+	if event.Type == services.FeedStandingsChanged && event.Table == nil {
+		if sim, err := api.Leagues.Get(event.LeagueID); err == nil {
+			snapshot := sim.Snapshot()
+			event.Table, event.Matches = snapshot.Table, snapshot.Matches
+		}
+	}
+	item := feedEventJSON(event)
+	if event.Type == services.FeedResync {
+		item = api.resyncJSON(event)
+	}
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
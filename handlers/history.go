@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"league-simulator/services"
+)
+
+func historyEventJSON(event services.HistoryEvent) map[string]any {
+	results := make([]map[string]any, 0, len(event.Results))
+	for _, result := range event.Results {
+		results = append(results, map[string]any{
+			"match_id":   result.MatchID,
+			"home_goals": result.HomeGoals,
+			"away_goals": result.AwayGoals,
+			"played":     result.Played,
+		})
+	}
+	item := map[string]any{
+		"seq":     event.Seq,
+		"type":    event.Type,
+		"time":    event.Time,
+		"results": results,
+	}
+	if event.Type == services.EventWeekSimulated {
+		item["week"] = event.Week
+	}
+	return item
+}
+
+func (api *API) GetHistory(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	history, cursor := sim.History()
+	events := make([]map[string]any, 0, len(history))
+	for i, event := range history {
+		item := historyEventJSON(event)
+		item["undone"] = i >= cursor
+		events = append(events, item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"cursor": cursor,
+		"events": events,
+	})
+}
+
+func (api *API) Undo(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := sim.Undo()
+	writeHistoryResult(w, "Event undone", event, err)
+}
+
+func (api *API) Redo(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	event, err := sim.Redo()
+	writeHistoryResult(w, "Event redone", event, err)
+}
+
+// writeHistoryResult reports the outcome of an undo or redo.
+func writeHistoryResult(w http.ResponseWriter, message string, event services.HistoryEvent, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"message": message,
+		"event":   historyEventJSON(event),
+	})
+}
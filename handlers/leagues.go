@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// league resolves the {id} route variable to a league, writing a 404 when it
+// does not exist.
+func (api *API) league(w http.ResponseWriter, r *http.Request) (services.LeagueSimulator, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid league ID", errInvalidParameter))
+		return nil, false
+	}
+	sim, err := api.Leagues.Get(id)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	return sim, true
+}
+
+// leagueSummary is the JSON representation of a league used by /leagues.
+func leagueSummary(sim services.LeagueSimulator) map[string]any {
+	snapshot := sim.Snapshot()
+	teams := make([]map[string]any, 0, len(snapshot.Teams))
+	for _, team := range snapshot.Teams {
+		teams = append(teams, teamJSON(team))
+	}
+	return map[string]any{
+		"id":           sim.ID(),
+		"name":         sim.Name(),
+		"teams":        teams,
+		"current_week": snapshot.CurrentWeek,
+		"total_weeks":  len(snapshot.Matches),
+		"season":       snapshot.Season,
+		"seed":         snapshot.Seed,
+		"tiebreakers":  services.TiebreakerNames(sim.Tiebreakers()),
+		"engine":       services.EngineName(sim.Engine()),
+	}
+}
+
+func (api *API) ListLeagues(w http.ResponseWriter, r *http.Request) {
+	leagues := []map[string]any{}
+	for _, sim := range api.Leagues.List() {
+		leagues = append(leagues, leagueSummary(sim))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leagues)
+}
+
+func (api *API) CreateLeague(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string        `json:"name"`
+		Seed        *int64        `json:"seed"`
+		Teams       []models.Team `json:"teams"`
+		Tiebreakers []string      `json:"tiebreakers"`
+		Engine      string        `json:"engine"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+
+	var opts []services.SimulatorOption
+	if req.Seed != nil {
+		opts = append(opts, services.WithSeed(*req.Seed))
+	}
+	if len(req.Tiebreakers) > 0 {
+		rules, err := services.ParseTiebreakers(req.Tiebreakers)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts = append(opts, services.WithTiebreakers(rules))
+	}
+	if req.Engine != "" {
+		engine, err := services.ParseEngine(req.Engine)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts = append(opts, services.WithMatchEngine(engine))
+	}
+	sim, err := api.Leagues.Create(req.Name, req.Teams, opts...)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(leagueSummary(sim))
+}
+
+func (api *API) GetLeague(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(leagueSummary(sim))
+}
+
+func (api *API) DeleteLeague(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.Leagues.Delete(sim.ID()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "League has been deleted"})
+}
@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// openAPISpec documents every route in RegisterRoutes. Request bodies are
+// validated against the schemas it declares.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+//go:embed swagger.html
+var swaggerPage []byte
+
+// schema is the subset of OpenAPI 3 schema objects the request validator
+// understands. additionalProperties is only honoured as a boolean.
+type schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Properties           map[string]*schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	Enum                 []any              `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MinItems             *int               `json:"minItems"`
+}
+
+// requestBody is the JSON request body declared for one operation.
+type requestBody struct {
+	required bool
+	schema   *schema
+}
+
+// specValidator checks request bodies against the embedded document.
+type specValidator struct {
+	schemas map[string]*schema
+	// bodies is keyed by method and OpenAPI path, e.g. "POST /leagues/{id}/match/edit".
+	bodies map[string]requestBody
+}
+
+// fieldError is one entry in the details of a validation_failed error.
+type fieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+var validator = mustLoadSpec(openAPISpec)
+
+func mustLoadSpec(raw []byte) *specValidator {
+	var doc struct {
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]*schema `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		panic(fmt.Sprintf("handlers: invalid openapi.json: %v", err))
+	}
+
+	v := &specValidator{schemas: doc.Components.Schemas, bodies: make(map[string]requestBody)}
+	for path, item := range doc.Paths {
+		// /v1 paths reuse the unversioned ones with a path item $ref
+		if ref, ok := item["$ref"]; ok {
+			var target string
+			if err := json.Unmarshal(ref, &target); err != nil {
+				panic(fmt.Sprintf("handlers: invalid $ref on %s: %v", path, err))
+			}
+			target = strings.NewReplacer("~1", "/", "~0", "~").Replace(strings.TrimPrefix(target, "#/paths/"))
+			if item = doc.Paths[target]; item == nil {
+				panic(fmt.Sprintf("handlers: %s refers to unknown path %s", path, target))
+			}
+		}
+		for method, rawOp := range item {
+			if method == "parameters" {
+				continue
+			}
+			var op struct {
+				RequestBody *struct {
+					Required bool `json:"required"`
+					Content  map[string]struct {
+						Schema *schema `json:"schema"`
+					} `json:"content"`
+				} `json:"requestBody"`
+			}
+			if err := json.Unmarshal(rawOp, &op); err != nil {
+				panic(fmt.Sprintf("handlers: invalid operation %s %s: %v", method, path, err))
+			}
+			if op.RequestBody == nil {
+				continue
+			}
+			if media, ok := op.RequestBody.Content["application/json"]; ok && media.Schema != nil {
+				v.bodies[strings.ToUpper(method)+" "+path] = requestBody{required: op.RequestBody.Required, schema: media.Schema}
+			}
+		}
+	}
+	return v
+}
+
+var routeVariable = regexp.MustCompile(`\{(\w+):[^}]*\}`)
+
+// openAPIPath turns a mux path template into its OpenAPI form by dropping
+// the variable patterns: "/leagues/{id:[0-9]+}" becomes "/leagues/{id}".
+func openAPIPath(template string) string {
+	return routeVariable.ReplaceAllString(template, "{$1}")
+}
+
+// validateRequest is a middleware that checks JSON request bodies against
+// the schema declared for the matched route. Failures are answered with 422
+// and one detail per offending field; routes without a declared body pass
+// through untouched.
+func validateRequest(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := mux.CurrentRoute(r)
+		if route == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, ok := validator.bodies[r.Method+" "+openAPIPath(template)]
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		raw, err := io.ReadAll(r.Body)
+		r.Body.Close()
+		if err != nil {
+			writeError(w, errInvalidBody)
+			return
+		}
+		// Handlers decode the body again, so hand them a fresh reader
+		r.Body = io.NopCloser(bytes.NewReader(raw))
+
+		if len(bytes.TrimSpace(raw)) == 0 {
+			if body.required {
+				writeErrorDetails(w, errValidation, []fieldError{{Message: "request body is required"}})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		var value any
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil {
+			writeError(w, errInvalidBody)
+			return
+		}
+
+		var errs []fieldError
+		validator.validate(body.schema, value, "", &errs)
+		if len(errs) > 0 {
+			writeErrorDetails(w, errValidation, errs)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validate appends an error for every way value breaks s. Values decoded
+// with UseNumber arrive as json.Number.
+func (v *specValidator) validate(s *schema, value any, field string, errs *[]fieldError) {
+	if s == nil {
+		return
+	}
+	if s.Ref != "" {
+		v.validate(v.schemas[strings.TrimPrefix(s.Ref, "#/components/schemas/")], value, field, errs)
+		return
+	}
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, fieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch s.Type {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		for _, name := range s.Required {
+			if _, ok := obj[name]; !ok {
+				*errs = append(*errs, fieldError{Field: joinField(field, name), Message: "is required"})
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					*errs = append(*errs, fieldError{Field: joinField(field, name), Message: "is not a known field"})
+				}
+				continue
+			}
+			v.validate(prop, obj[name], joinField(field, name), errs)
+		}
+	case "array":
+		items, ok := value.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(items) < *s.MinItems {
+			fail("must have at least %d items", *s.MinItems)
+		}
+		for i, item := range items {
+			v.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i), errs)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		if s.MinLength != nil && len(strings.TrimSpace(str)) < *s.MinLength {
+			fail("must be at least %d characters", *s.MinLength)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	case "integer", "number":
+		num, ok := value.(json.Number)
+		if !ok {
+			if s.Type == "integer" {
+				fail("must be an integer")
+			} else {
+				fail("must be a number")
+			}
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := num.Int64(); err != nil {
+				fail("must be an integer")
+				return
+			}
+		}
+		f, _ := num.Float64()
+		if s.Minimum != nil && f < *s.Minimum {
+			fail("must be >= %v", *s.Minimum)
+		}
+		if s.Maximum != nil && f > *s.Maximum {
+			fail("must be <= %v", *s.Maximum)
+		}
+	}
+
+	if len(s.Enum) > 0 {
+		for _, allowed := range s.Enum {
+			if fmt.Sprint(allowed) == fmt.Sprint(value) {
+				return
+			}
+		}
+		fail("must be one of %v", s.Enum)
+	}
+}
+
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// OpenAPI serves the embedded OpenAPI 3 document.
+func (api *API) OpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// Docs serves a Swagger UI page for the OpenAPI document.
+func (api *API) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(swaggerPage)
+}
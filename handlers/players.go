@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// defaultTopScorers is how many players /stats/top-scorers lists by default.
+const defaultTopScorers = 10
+
+// playerRequest is the body accepted by POST and PUT /players. Omitted fields
+// keep their current value on PUT; team_id moves the player to another team.
+type playerRequest struct {
+	Name     *string `json:"name"`
+	Position *string `json:"position"`
+	Rating   *int    `json:"rating"`
+	TeamID   *int    `json:"team_id"`
+}
+
+// apply copies the fields present in the request onto player.
+func (req playerRequest) apply(player *models.Player) {
+	if req.Name != nil {
+		player.Name = *req.Name
+	}
+	if req.Position != nil {
+		player.Position = *req.Position
+	}
+	if req.Rating != nil {
+		player.Rating = *req.Rating
+	}
+	if req.TeamID != nil {
+		player.TeamID = *req.TeamID
+	}
+}
+
+func playerJSON(player models.Player) map[string]any {
+	return map[string]any{
+		"id":       player.ID,
+		"team_id":  player.TeamID,
+		"name":     player.Name,
+		"position": player.Position,
+		"rating":   player.Rating,
+	}
+}
+
+func playerStatsJSON(stats services.PlayerStats) map[string]any {
+	return map[string]any{
+		"player":       playerJSON(stats.Player),
+		"team":         newTeamRef(stats.Team),
+		"goals":        stats.Goals,
+		"yellow_cards": stats.YellowCards,
+		"red_cards":    stats.RedCards,
+	}
+}
+
+// playerID parses the {playerId} route variable.
+func playerID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["playerId"])
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid player ID", errInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+// teamPlayer resolves {teamId} and {playerId} to a player of that team,
+// writing a 404 when the player is in another team or does not exist.
+func teamPlayer(w http.ResponseWriter, r *http.Request, sim services.LeagueSimulator) (models.Player, bool) {
+	team, ok := teamID(w, r)
+	if !ok {
+		return models.Player{}, false
+	}
+	id, ok := playerID(w, r)
+	if !ok {
+		return models.Player{}, false
+	}
+	player, err := sim.Player(id)
+	if err == nil && player.TeamID != team {
+		err = services.ErrPlayerNotFound
+	}
+	if err != nil {
+		writeError(w, err)
+		return models.Player{}, false
+	}
+	return player, true
+}
+
+func (api *API) ListPlayers(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	team, ok := teamID(w, r)
+	if !ok {
+		return
+	}
+
+	squad, err := sim.Players(team)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	players := make([]map[string]any, 0, len(squad))
+	for _, player := range squad {
+		players = append(players, playerJSON(player))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(players)
+}
+
+func (api *API) GetPlayer(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	player, ok := teamPlayer(w, r, sim)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(playerJSON(player))
+}
+
+func (api *API) CreatePlayer(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	team, ok := teamID(w, r)
+	if !ok {
+		return
+	}
+
+	var req playerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+	player := models.Player{TeamID: team}
+	req.TeamID = nil
+	req.apply(&player)
+
+	player, err := sim.AddPlayer(player)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(playerJSON(player))
+}
+
+func (api *API) UpdatePlayer(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	player, ok := teamPlayer(w, r, sim)
+	if !ok {
+		return
+	}
+
+	var req playerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+	player, err := sim.UpdatePlayer(player.ID, services.PlayerUpdate(req))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(playerJSON(player))
+}
+
+func (api *API) DeletePlayer(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	player, ok := teamPlayer(w, r, sim)
+	if !ok {
+		return
+	}
+
+	if err := sim.RemovePlayer(player.ID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Player has been deleted"})
+}
+
+// TopScorers lists the players with at least one goal, most goals first.
+func (api *API) TopScorers(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultTopScorers
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			writeError(w, fmt.Errorf("%w: limit must be a positive integer", errInvalidParameter))
+			return
+		}
+		limit = n
+	}
+
+	scorers := []map[string]any{}
+	for _, stats := range sim.PlayerStats() {
+		if stats.Goals == 0 || len(scorers) == limit {
+			break
+		}
+		scorers = append(scorers, playerStatsJSON(stats))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scorers)
+}
+
+// GetPlayerStats returns one player's season record.
+func (api *API) GetPlayerStats(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	id, ok := playerID(w, r)
+	if !ok {
+		return
+	}
+
+	for _, stats := range sim.PlayerStats() {
+		if stats.Player.ID == id {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(playerStatsJSON(stats))
+			return
+		}
+	}
+	writeError(w, services.ErrPlayerNotFound)
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"league-simulator/services"
+)
+
+// Playoff statuses.
+const (
+	PlayoffPending    = "pending"
+	PlayoffInProgress = "in_progress"
+	PlayoffDecided    = "decided"
+)
+
+// playoffJSON is a league's playoff: its places and outcome, the bracket
+// once it has been drawn, and the winner once the final has been played.
+func playoffJSON(playoff services.Playoff) map[string]any {
+	status := PlayoffPending
+	switch {
+	case playoff.Decided():
+		status = PlayoffDecided
+	case len(playoff.Rounds) > 0:
+		status = PlayoffInProgress
+	}
+	return map[string]any{
+		"name":    playoff.Config.Name,
+		"from":    playoff.Config.From,
+		"to":      playoff.Config.To,
+		"legs":    playoff.Config.Legs,
+		"outcome": playoff.Config.Outcome,
+		"status":  status,
+		"rounds":  bracketJSON(playoff.Rounds),
+		"winner":  optionalTeamRef(playoff.Winner),
+	}
+}
+
+// GetPlayoff returns the league's playoff for the current season.
+func (api *API) GetPlayoff(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	playoff, err := sim.Playoff()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(playoffJSON(playoff))
+}
+
+// SetPlayoff attaches a playoff to the league, replacing any existing one
+// and its bracket.
+func (api *API) SetPlayoff(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Name    string `json:"name"`
+		From    int    `json:"from"`
+		To      int    `json:"to"`
+		Legs    int    `json:"legs"`
+		Outcome string `json:"outcome"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+
+	if _, err := sim.SetPlayoff(services.PlayoffConfig(req)); err != nil {
+		writeError(w, err)
+		return
+	}
+	playoff, err := sim.Playoff()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(playoffJSON(playoff))
+}
+
+func (api *API) DeletePlayoff(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	if err := sim.RemovePlayoff(); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Playoff has been removed"})
+}
+
+// SimulatePlayoffRound plays the next playoff round, drawing the bracket
+// from the final table before the first one.
+func (api *API) SimulatePlayoffRound(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	played, err := sim.SimulatePlayoffRound()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	playoff, err := sim.Playoff()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := playoffJSON(playoff)
+	resp["message"] = fmt.Sprintf("%s simulated", played.Name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SimulatePlayoffAll plays the rest of the playoff.
+func (api *API) SimulatePlayoffAll(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	playoff, err := sim.SimulatePlayoff()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	resp := playoffJSON(playoff)
+	resp["message"] = fmt.Sprintf("%s simulated", playoff.Config.Name)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,268 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ZonedStandingResponse is a table row with the zone its position falls in:
+// promotion, playoff, relegation, or omitted for mid-table.
+type ZonedStandingResponse struct {
+	StandingResponse
+	Zone string `json:"zone,omitempty"`
+}
+
+// registerPyramidRoutes adds the pyramid endpoints to router. They are the
+// same in every API version.
+func (api *API) registerPyramidRoutes(router *mux.Router) {
+	router.HandleFunc("/pyramids", api.ListPyramids).Methods("GET")
+	router.HandleFunc("/pyramids", api.CreatePyramid).Methods("POST")
+
+	pyramid := router.PathPrefix("/pyramids/{id:[0-9]+}").Subrouter()
+	pyramid.HandleFunc("", api.GetPyramid).Methods("GET")
+	pyramid.HandleFunc("", api.DeletePyramid).Methods("DELETE")
+	pyramid.HandleFunc("/simulate/week", api.SimulatePyramidWeek).Methods("POST")
+	pyramid.HandleFunc("/simulate/season", api.SimulatePyramidSeason).Methods("POST")
+	pyramid.HandleFunc("/seasons", api.GetPyramidSeasons).Methods("GET")
+
+	// /pyramid is the newest pyramid, for clients that run one at a time.
+	router.HandleFunc("/pyramid", api.GetPyramid).Methods("GET")
+}
+
+// pyramid resolves the {id} route variable to a pyramid, or to the newest
+// pyramid on routes without one, writing a 404 when it does not exist.
+func (api *API) pyramid(w http.ResponseWriter, r *http.Request) (*services.Pyramid, bool) {
+	raw, ok := mux.Vars(r)["id"]
+	if !ok {
+		pyramids := api.Leagues.ListPyramids()
+		if len(pyramids) == 0 {
+			writeError(w, services.ErrPyramidNotFound)
+			return nil, false
+		}
+		return pyramids[len(pyramids)-1], true
+	}
+	id, err := strconv.Atoi(raw)
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid pyramid ID", errInvalidParameter))
+		return nil, false
+	}
+	p, err := api.Leagues.GetPyramid(id)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	return p, true
+}
+
+func pyramidSummary(p *services.Pyramid) map[string]any {
+	season, over := p.Season()
+	return map[string]any{
+		"id":          p.ID(),
+		"name":        p.Name(),
+		"season":      season,
+		"season_over": over,
+		"seed":        p.Seed(),
+		"engine":      services.EngineName(p.Engine()),
+		"tiebreakers": services.TiebreakerNames(p.Tiebreakers()),
+	}
+}
+
+// divisionJSON is a division's table with zones. matches may be nil for
+// archived seasons, in which case the form guide is empty.
+func divisionJSON(tier int, division services.DivisionTable) map[string]any {
+	rows := newStandingResponses(division.Table, division.Matches)
+	table := make([]ZonedStandingResponse, len(rows))
+	for i, row := range rows {
+		table[i] = ZonedStandingResponse{StandingResponse: row, Zone: division.Zone(row.Position)}
+	}
+	return map[string]any{
+		"name":       division.Name,
+		"tier":       tier,
+		"promotion":  division.Promotion,
+		"playoff":    division.Playoff,
+		"relegation": division.Relegation,
+		"table":      table,
+	}
+}
+
+// pyramidJSON is the pyramid with every division's current table.
+func pyramidJSON(p *services.Pyramid) map[string]any {
+	divisions := []map[string]any{}
+	for i, division := range p.Divisions() {
+		resp := divisionJSON(i+1, division)
+		resp["current_week"] = division.CurrentWeek
+		resp["total_weeks"] = len(division.Matches)
+		divisions = append(divisions, resp)
+	}
+	resp := pyramidSummary(p)
+	resp["divisions"] = divisions
+	return resp
+}
+
+func seasonJSON(season services.PyramidSeason) map[string]any {
+	tables := make([]map[string]any, 0, len(season.Tables))
+	for i, division := range season.Tables {
+		tables = append(tables, divisionJSON(i+1, division))
+	}
+	playoffs := make([]map[string]any, 0, len(season.Playoffs))
+	for _, playoff := range season.Playoffs {
+		playoffs = append(playoffs, map[string]any{
+			"division": playoff.Division,
+			"winner":   newTeamRef(playoff.Winner),
+			"rounds":   bracketJSON(playoff.Rounds),
+		})
+	}
+	movements := make([]map[string]any, 0, len(season.Movements))
+	for _, move := range season.Movements {
+		movements = append(movements, map[string]any{
+			"team":    newTeamRef(move.Team),
+			"from":    move.From,
+			"to":      move.To,
+			"playoff": move.Playoff,
+		})
+	}
+	return map[string]any{
+		"season":    season.Number,
+		"divisions": tables,
+		"playoffs":  playoffs,
+		"movements": movements,
+	}
+}
+
+func (api *API) ListPyramids(w http.ResponseWriter, r *http.Request) {
+	pyramids := []map[string]any{}
+	for _, p := range api.Leagues.ListPyramids() {
+		pyramids = append(pyramids, pyramidSummary(p))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pyramids)
+}
+
+// CreatePyramid builds a pyramid from divisions listed top tier first.
+func (api *API) CreatePyramid(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string   `json:"name"`
+		Seed        *int64   `json:"seed"`
+		Engine      string   `json:"engine"`
+		Tiebreakers []string `json:"tiebreakers"`
+		Divisions   []struct {
+			Name       string        `json:"name"`
+			Teams      []models.Team `json:"teams"`
+			Promotion  int           `json:"promotion"`
+			Playoff    int           `json:"playoff"`
+			Relegation int           `json:"relegation"`
+		} `json:"divisions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+
+	opts := services.PyramidOptions{Seed: req.Seed}
+	if len(req.Tiebreakers) > 0 {
+		rules, err := services.ParseTiebreakers(req.Tiebreakers)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts.Tiebreakers = rules
+	}
+	if req.Engine != "" {
+		engine, err := services.ParseEngine(req.Engine)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts.Engine = engine
+	}
+	divisions := make([]services.DivisionConfig, 0, len(req.Divisions))
+	for _, d := range req.Divisions {
+		divisions = append(divisions, services.DivisionConfig(d))
+	}
+	p, err := api.Leagues.CreatePyramid(req.Name, divisions, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(pyramidJSON(p))
+}
+
+// GetPyramid returns every division's table with the promotion, playoff and
+// relegation zones marked.
+func (api *API) GetPyramid(w http.ResponseWriter, r *http.Request) {
+	p, ok := api.pyramid(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pyramidJSON(p))
+}
+
+func (api *API) DeletePyramid(w http.ResponseWriter, r *http.Request) {
+	p, ok := api.pyramid(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.Leagues.DeletePyramid(p.ID()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Pyramid has been deleted"})
+}
+
+func (api *API) SimulatePyramidWeek(w http.ResponseWriter, r *http.Request) {
+	p, ok := api.pyramid(w, r)
+	if !ok {
+		return
+	}
+
+	if err := p.SimulateWeek(); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pyramidJSON(p))
+}
+
+func (api *API) SimulatePyramidSeason(w http.ResponseWriter, r *http.Request) {
+	p, ok := api.pyramid(w, r)
+	if !ok {
+		return
+	}
+
+	if err := p.SimulateSeason(); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pyramidJSON(p))
+}
+
+// GetPyramidSeasons returns every settled season: final tables, playoffs and
+// the teams promoted and relegated.
+func (api *API) GetPyramidSeasons(w http.ResponseWriter, r *http.Request) {
+	p, ok := api.pyramid(w, r)
+	if !ok {
+		return
+	}
+
+	seasons := []map[string]any{}
+	for _, season := range p.Seasons() {
+		seasons = append(seasons, seasonJSON(season))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(seasons)
+}
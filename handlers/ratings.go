@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"league-simulator/models"
+)
+
+// ratingsJSON lists ratings highest first. previous, when not nil, adds each
+// team's change since then.
+func ratingsJSON(teams []models.Team, ratings, previous map[int]float64) []map[string]any {
+	items := make([]map[string]any, 0, len(teams))
+	for _, team := range teams {
+		rating, ok := ratings[team.ID]
+		if !ok {
+			continue
+		}
+		item := map[string]any{
+			"team_id":   team.ID,
+			"team_name": team.Name,
+			"rating":    round(rating, 1),
+		}
+		if previous != nil {
+			item["change"] = round(rating-previous[team.ID], 1)
+		}
+		items = append(items, item)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i]["rating"].(float64) > items[j]["rating"].(float64)
+	})
+	return items
+}
+
+// GetRatings returns the league's current Elo ratings and their history,
+// one entry per played week starting from the pre-season ratings at week 0.
+func (api *API) GetRatings(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	snapshot := sim.RatingHistory()
+	teams, history := snapshot.Teams, snapshot.Weeks
+	weeks := make([]map[string]any, 0, len(history))
+	for week, ratings := range history {
+		weeks = append(weeks, map[string]any{
+			"week":    week,
+			"ratings": ratingsJSON(teams, ratings, nil),
+		})
+	}
+	previous := history[0]
+	if len(history) > 1 {
+		previous = history[len(history)-2]
+	}
+
+	elo := sim.Elo()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"k_factor":       elo.K,
+		"home_advantage": elo.HomeAdvantage,
+		"initial_rating": elo.Initial,
+		"ratings":        ratingsJSON(teams, history[len(history)-1], previous),
+		"history":        weeks,
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// strengthChangesJSON lists how each team's strength developed after a
+// season, champion first.
+func strengthChangesJSON(changes []services.StrengthChange) []map[string]any {
+	resp := make([]map[string]any, 0, len(changes))
+	for _, change := range changes {
+		resp = append(resp, map[string]any{
+			"team":   newTeamRef(change.Team),
+			"before": change.Before,
+			"after":  change.After,
+		})
+	}
+	return resp
+}
+
+// seasonSummary describes a season for the season list. champion is null
+// until the season has been played out; with a title playoff it is the
+// playoff winner, and with a promotion playoff the winner is reported as
+// promoted, though it stays in the league.
+func seasonSummary(number int, table []models.Standing, matches [][]models.Match, playoff *services.Playoff, current bool) map[string]any {
+	played := 0
+	total := 0
+	for _, weekMatches := range matches {
+		for _, match := range weekMatches {
+			total++
+			if match.Played {
+				played++
+			}
+		}
+	}
+	var champion, promoted *TeamRef
+	if played == total && len(table) > 0 {
+		ref := newTeamRef(table[0].Team)
+		champion = &ref
+	}
+	var playoffResp map[string]any
+	if playoff != nil {
+		playoffResp = playoffJSON(*playoff)
+		switch playoff.Config.Outcome {
+		case services.PlayoffChampion:
+			champion = optionalTeamRef(playoff.Winner)
+		case services.PlayoffPromotion:
+			promoted = optionalTeamRef(playoff.Winner)
+		}
+	}
+	return map[string]any{
+		"season":         number,
+		"current":        current,
+		"matches_played": played,
+		"total_matches":  total,
+		"champion":       champion,
+		"promoted":       promoted,
+		"playoff":        playoffResp,
+	}
+}
+
+// currentPlayoff is the league's playoff, or nil when it has none.
+func currentPlayoff(sim services.LeagueSimulator) *services.Playoff {
+	playoff, err := sim.Playoff()
+	if err != nil {
+		return nil
+	}
+	return &playoff
+}
+
+// ListSeasons returns the archived seasons, oldest first, followed by the
+// current one.
+func (api *API) ListSeasons(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	seasons := []map[string]any{}
+	for _, season := range sim.Seasons() {
+		seasons = append(seasons, seasonSummary(season.Number, season.Table, season.Matches, season.Playoff, false))
+	}
+	snapshot := sim.Snapshot()
+	seasons = append(seasons, seasonSummary(snapshot.Season, snapshot.Table, snapshot.Matches, currentPlayoff(sim), true))
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(seasons)
+}
+
+// NextSeason archives the finished season and starts the next one with
+// developed team strengths.
+func (api *API) NextSeason(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	archived, err := sim.NextSeason()
+	if err != nil {
+		writeError(w, err) // 409 while matches or the playoff are left to play
+		return
+	}
+	resp := seasonSummary(archived.Number, archived.Table, archived.Matches, archived.Playoff, false)
+	resp["strength_changes"] = strengthChangesJSON(archived.Changes)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"message":  fmt.Sprintf("Season %d archived", archived.Number),
+		"archived": resp,
+		"league":   leagueSummary(sim),
+	})
+}
+
+// GetSeasonStandings returns the final table of an archived season, or the
+// live table of the current one.
+func (api *API) GetSeasonStandings(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	number, err := strconv.Atoi(mux.Vars(r)["season"])
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid season", errInvalidParameter))
+		return
+	}
+
+	var resp map[string]any
+	seasons := sim.Seasons()
+	snapshot := sim.Snapshot()
+	switch {
+	case number >= 1 && number <= len(seasons):
+		season := seasons[number-1]
+		resp = seasonSummary(season.Number, season.Table, season.Matches, season.Playoff, false)
+		resp["table"] = newStandingResponses(season.Table, season.Matches)
+		resp["strength_changes"] = strengthChangesJSON(season.Changes)
+	case number == snapshot.Season:
+		resp = seasonSummary(snapshot.Season, snapshot.Table, snapshot.Matches, currentPlayoff(sim), true)
+		resp["table"] = newStandingResponses(snapshot.Table, snapshot.Matches)
+	default:
+		writeError(w, fmt.Errorf("%w: league %d is in season %d", services.ErrSeasonNotFound, sim.ID(), snapshot.Season))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// teamRequest is the body accepted by POST and PUT /teams. Omitted fields
+// keep their current value on PUT.
+type teamRequest struct {
+	Name     *string `json:"name"`
+	Strength *int    `json:"strength"`
+	Attack   *int    `json:"attack"`
+	Defence  *int    `json:"defence"`
+}
+
+// apply copies the fields present in the request onto team.
+func (req teamRequest) apply(team *models.Team) {
+	if req.Name != nil {
+		team.Name = *req.Name
+	}
+	if req.Strength != nil {
+		team.Strength = *req.Strength
+	}
+	if req.Attack != nil {
+		team.Attack = *req.Attack
+	}
+	if req.Defence != nil {
+		team.Defence = *req.Defence
+	}
+}
+
+func teamJSON(team models.Team) map[string]any {
+	return map[string]any{
+		"id":       team.ID,
+		"name":     team.Name,
+		"strength": team.Strength,
+		"attack":   team.Attack,
+		"defence":  team.Defence,
+	}
+}
+
+// teamID parses the {teamId} route variable.
+func teamID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["teamId"])
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid team ID", errInvalidParameter))
+		return 0, false
+	}
+	return id, true
+}
+
+func (api *API) ListTeams(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	teams := []map[string]any{}
+	for _, team := range sim.Teams() {
+		teams = append(teams, teamJSON(team))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(teams)
+}
+
+func (api *API) GetTeam(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	id, ok := teamID(w, r)
+	if !ok {
+		return
+	}
+
+	for _, team := range sim.Teams() {
+		if team.ID == id {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(teamJSON(team))
+			return
+		}
+	}
+	writeError(w, services.ErrTeamNotFound)
+}
+
+func (api *API) CreateTeam(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	var req teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+	var team models.Team
+	req.apply(&team)
+
+	team, err := sim.AddTeam(team)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(teamJSON(team))
+}
+
+func (api *API) UpdateTeam(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	id, ok := teamID(w, r)
+	if !ok {
+		return
+	}
+
+	var req teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+
+	team, err := sim.UpdateTeam(id, services.TeamUpdate(req))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(teamJSON(team))
+}
+
+func (api *API) DeleteTeam(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	id, ok := teamID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := sim.RemoveTeam(id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Team has been deleted"})
+}
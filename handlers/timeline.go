@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+
+	"github.com/gorilla/mux"
+)
+
+// ScoreResponse is a score part way through a match.
+type ScoreResponse struct {
+	HomeGoals int `json:"home_goals"`
+	AwayGoals int `json:"away_goals"`
+}
+
+// MatchEventResponse is one entry of a match timeline. AddedTime counts
+// minutes into stoppage time, so 45+2 is minute 45 with added_time 2.
+type MatchEventResponse struct {
+	Minute    int     `json:"minute"`
+	AddedTime int     `json:"added_time,omitempty"`
+	Type      string  `json:"type"`
+	Team      TeamRef `json:"team"`
+	// Player is the scorer or booked player, when the team has a squad.
+	Player *PlayerRef `json:"player,omitempty"`
+}
+
+// PlayerRef identifies a player inside another resource.
+type PlayerRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// TimelineResponse is a match with its half-time score and events. Matches
+// that are unplayed, or whose result was edited, have no timeline: HalfTime
+// is null and Events empty.
+type TimelineResponse struct {
+	MatchResponse
+	HalfTime *ScoreResponse       `json:"half_time"`
+	Events   []MatchEventResponse `json:"events"`
+}
+
+// newTimelineResponse builds the timeline of match. players names the
+// players events refer to; players since removed are shown by ID only.
+func newTimelineResponse(match models.Match, players map[int]models.Player) TimelineResponse {
+	resp := TimelineResponse{
+		MatchResponse: newMatchResponse(match),
+		Events:        make([]MatchEventResponse, 0, len(match.Events)),
+	}
+	if match.HalfTime != nil {
+		resp.HalfTime = &ScoreResponse{HomeGoals: match.HalfTime.HomeGoals, AwayGoals: match.HalfTime.AwayGoals}
+	}
+	for _, event := range match.Events {
+		team := match.Home
+		if event.TeamID == match.Away.ID {
+			team = match.Away
+		}
+		item := MatchEventResponse{
+			Minute:    event.Minute,
+			AddedTime: event.AddedTime,
+			Type:      event.Type,
+			Team:      newTeamRef(team),
+		}
+		if event.PlayerID != 0 {
+			item.Player = &PlayerRef{ID: event.PlayerID, Name: players[event.PlayerID].Name}
+		}
+		resp.Events = append(resp.Events, item)
+	}
+	return resp
+}
+
+// GetMatchEvents returns a match's timeline: goals, cards and substitutions
+// by minute, and the half-time score.
+func (api *API) GetMatchEvents(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["matchId"])
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid match ID", errInvalidParameter))
+		return
+	}
+
+	match, err := sim.GetMatchByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	players := make(map[int]models.Player)
+	for _, event := range match.Events {
+		if player, err := sim.Player(event.PlayerID); err == nil {
+			players[player.ID] = player
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newTimelineResponse(*match, players))
+}
@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// registerTournamentRoutes adds the tournament endpoints to router. They are
+// the same in every API version.
+func (api *API) registerTournamentRoutes(router *mux.Router) {
+	router.HandleFunc("/tournaments", api.ListTournaments).Methods("GET")
+	router.HandleFunc("/tournaments", api.CreateTournament).Methods("POST")
+
+	tournament := router.PathPrefix("/tournaments/{id:[0-9]+}").Subrouter()
+	tournament.HandleFunc("", api.GetTournament).Methods("GET")
+	tournament.HandleFunc("", api.DeleteTournament).Methods("DELETE")
+	tournament.HandleFunc("/simulate/next", api.SimulateTournamentNext).Methods("POST")
+	tournament.HandleFunc("/simulate/all", api.SimulateTournamentAll).Methods("POST")
+}
+
+// tournament resolves the {id} route variable to a tournament, writing a 404
+// when it does not exist.
+func (api *API) tournament(w http.ResponseWriter, r *http.Request) (*services.Tournament, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid tournament ID", errInvalidParameter))
+		return nil, false
+	}
+	t, err := api.Leagues.GetTournament(id)
+	if err != nil {
+		writeError(w, err)
+		return nil, false
+	}
+	return t, true
+}
+
+// tournamentSummary is the JSON representation of a tournament used by the
+// list endpoint.
+func tournamentSummary(t *services.Tournament) map[string]any {
+	summary := map[string]any{
+		"id":          t.ID(),
+		"name":        t.Name(),
+		"stage":       t.Stage(),
+		"seed":        t.Seed(),
+		"engine":      services.EngineName(t.Engine()),
+		"tiebreakers": services.TiebreakerNames(t.Tiebreakers()),
+		"advance":     t.Advance(),
+		"legs":        t.Legs(),
+		"champion":    nil,
+	}
+	if knockout := t.Knockout(); knockout != nil {
+		summary["champion"] = championJSON(knockout)
+	}
+	return summary
+}
+
+// tournamentJSON is the whole tournament: the draw by pot, every group with
+// its table and fixtures, and the knockout bracket once the group stage is
+// over.
+func tournamentJSON(t *services.Tournament) map[string]any {
+	groups := t.Groups()
+	groupsJSON := make([]map[string]any, 0, len(groups))
+	for _, group := range groups {
+		entrants := make([]map[string]any, 0, len(group.Entrants))
+		teams := make([]models.Team, 0, len(group.Entrants))
+		for _, entrant := range group.Entrants {
+			entrants = append(entrants, map[string]any{
+				"team":    newTeamRef(entrant.Team),
+				"country": entrant.Country,
+				"pot":     entrant.Pot,
+			})
+			teams = append(teams, entrant.Team)
+		}
+		weeks := make([]WeekResponse, 0, len(group.Matches))
+		for i, weekMatches := range group.Matches {
+			weeks = append(weeks, newWeekResponse(i+1, weekMatches, teams))
+		}
+		groupsJSON = append(groupsJSON, map[string]any{
+			"name":     group.Name,
+			"entrants": entrants,
+			"table":    newStandingResponses(group.Table, group.Matches),
+			"matches":  weeks,
+		})
+	}
+
+	// The draw lists each pot with the group every team was drawn into
+	potTeams := make(map[int][]map[string]any)
+	for _, group := range groups {
+		for _, entrant := range group.Entrants {
+			potTeams[entrant.Pot] = append(potTeams[entrant.Pot], map[string]any{
+				"team":    newTeamRef(entrant.Team),
+				"country": entrant.Country,
+				"group":   group.Name,
+			})
+		}
+	}
+	potNumbers := make([]int, 0, len(potTeams))
+	for pot := range potTeams {
+		potNumbers = append(potNumbers, pot)
+	}
+	sort.Ints(potNumbers)
+	pots := make([]map[string]any, 0, len(potNumbers))
+	for _, pot := range potNumbers {
+		pots = append(pots, map[string]any{"pot": pot, "teams": potTeams[pot]})
+	}
+
+	resp := tournamentSummary(t)
+	resp["draw"] = pots
+	resp["groups"] = groupsJSON
+	resp["knockout"] = nil
+	if knockout := t.Knockout(); knockout != nil {
+		resp["knockout"] = map[string]any{"rounds": bracketJSON(knockout.Bracket())}
+	}
+	return resp
+}
+
+func (api *API) ListTournaments(w http.ResponseWriter, r *http.Request) {
+	tournaments := []map[string]any{}
+	for _, t := range api.Leagues.ListTournaments() {
+		tournaments = append(tournaments, tournamentSummary(t))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tournaments)
+}
+
+// CreateTournament draws the entrants into groups. Teams without a pot are
+// potted in the order given.
+func (api *API) CreateTournament(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name        string   `json:"name"`
+		Seed        *int64   `json:"seed"`
+		Groups      int      `json:"groups"`
+		Advance     int      `json:"advance"`
+		Legs        int      `json:"legs"`
+		Engine      string   `json:"engine"`
+		Tiebreakers []string `json:"tiebreakers"`
+		Teams       []struct {
+			models.Team
+			Country string `json:"country"`
+			Pot     int    `json:"pot"`
+		} `json:"teams"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, errInvalidBody)
+		return
+	}
+
+	opts := services.TournamentOptions{Groups: req.Groups, Advance: req.Advance, Legs: req.Legs, Seed: req.Seed}
+	if len(req.Tiebreakers) > 0 {
+		rules, err := services.ParseTiebreakers(req.Tiebreakers)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts.Tiebreakers = rules
+	}
+	if req.Engine != "" {
+		engine, err := services.ParseEngine(req.Engine)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		opts.Engine = engine
+	}
+	entrants := make([]services.Entrant, 0, len(req.Teams))
+	for _, team := range req.Teams {
+		entrants = append(entrants, services.Entrant{Team: team.Team, Country: team.Country, Pot: team.Pot})
+	}
+	t, err := api.Leagues.CreateTournament(req.Name, entrants, opts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tournamentJSON(t))
+}
+
+func (api *API) GetTournament(w http.ResponseWriter, r *http.Request) {
+	t, ok := api.tournament(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tournamentJSON(t))
+}
+
+func (api *API) DeleteTournament(w http.ResponseWriter, r *http.Request) {
+	t, ok := api.tournament(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.Leagues.DeleteTournament(t.ID()); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Tournament has been deleted"})
+}
+
+// SimulateTournamentNext plays the next group matchday or knockout round and
+// returns the updated tournament.
+func (api *API) SimulateTournamentNext(w http.ResponseWriter, r *http.Request) {
+	t, ok := api.tournament(w, r)
+	if !ok {
+		return
+	}
+
+	played, err := t.SimulateNext()
+	if err != nil {
+		writeError(w, err) // 409 once the final has been played
+		return
+	}
+	resp := tournamentJSON(t)
+	resp["message"] = played + " simulated"
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SimulateTournamentAll plays the rest of the group stage and the knockout.
+func (api *API) SimulateTournamentAll(w http.ResponseWriter, r *http.Request) {
+	t, ok := api.tournament(w, r)
+	if !ok {
+		return
+	}
+
+	t.SimulateAll()
+	resp := tournamentJSON(t)
+	resp["message"] = "Tournament simulated"
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"league-simulator/models"
+	"league-simulator/services"
+
+	"github.com/gorilla/mux"
+)
+
+// The types below are the /v1 wire format. Handlers under /v1 only ever
+// encode these, so the models can change without changing the responses.
+
+// TeamRef identifies a team inside another resource.
+type TeamRef struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// StandingResponse is one row of the league table.
+type StandingResponse struct {
+	Position       int     `json:"position"`
+	Team           TeamRef `json:"team"`
+	Played         int     `json:"played"`
+	Won            int     `json:"won"`
+	Drawn          int     `json:"drawn"`
+	Lost           int     `json:"lost"`
+	GoalsFor       int     `json:"goals_for"`
+	GoalsAgainst   int     `json:"goals_against"`
+	GoalDifference int     `json:"goal_difference"`
+	Points         int     `json:"points"`
+	FairPlay       int     `json:"fair_play"`
+	// Tiebreaker names the rule that put the team below the one above it.
+	Tiebreaker string `json:"tiebreaker,omitempty"`
+	// Form lists the last five results, oldest first, e.g. "WWDLW".
+	Form string `json:"form"`
+}
+
+// Match statuses.
+const (
+	MatchScheduled = "scheduled"
+	MatchPlayed    = "played"
+)
+
+// MatchResponse is a single fixture. Goals are null until it is played.
+type MatchResponse struct {
+	ID        int     `json:"id"`
+	Week      int     `json:"week"`
+	Home      TeamRef `json:"home"`
+	Away      TeamRef `json:"away"`
+	HomeGoals *int    `json:"home_goals"`
+	AwayGoals *int    `json:"away_goals"`
+	Status    string  `json:"status"`
+}
+
+// Week statuses.
+const (
+	WeekScheduled  = "scheduled"
+	WeekInProgress = "in_progress"
+	WeekCompleted  = "completed"
+)
+
+// WeekResponse groups the fixtures of one week.
+type WeekResponse struct {
+	Week    int             `json:"week"`
+	Status  string          `json:"status"`
+	Matches []MatchResponse `json:"matches"`
+	Bye     *TeamRef        `json:"bye,omitempty"`
+}
+
+// formLength is how many recent results the form guide shows.
+const formLength = 5
+
+func newTeamRef(team models.Team) TeamRef {
+	return TeamRef{ID: team.ID, Name: team.Name}
+}
+
+func newMatchResponse(match models.Match) MatchResponse {
+	resp := MatchResponse{
+		ID:     match.ID,
+		Week:   match.Week,
+		Home:   newTeamRef(match.Home),
+		Away:   newTeamRef(match.Away),
+		Status: MatchScheduled,
+	}
+	if match.Played {
+		homeGoals, awayGoals := match.HomeGoals, match.AwayGoals
+		resp.HomeGoals, resp.AwayGoals = &homeGoals, &awayGoals
+		resp.Status = MatchPlayed
+	}
+	return resp
+}
+
+func newWeekResponse(week int, weekMatches []models.Match, teams []models.Team) WeekResponse {
+	resp := WeekResponse{Week: week, Matches: make([]MatchResponse, 0, len(weekMatches))}
+	played := 0
+	for _, match := range weekMatches {
+		resp.Matches = append(resp.Matches, newMatchResponse(match))
+		if match.Played {
+			played++
+		}
+	}
+	switch {
+	case played == 0:
+		resp.Status = WeekScheduled
+	case played < len(weekMatches):
+		resp.Status = WeekInProgress
+	default:
+		resp.Status = WeekCompleted
+	}
+	if resting := services.RestingTeams(teams, weekMatches); len(resting) > 0 {
+		bye := newTeamRef(resting[0])
+		resp.Bye = &bye
+	}
+	return resp
+}
+
+// newStandingResponses numbers the ranked table and attaches each team's
+// form from the fixture list.
+func newStandingResponses(table []models.Standing, matches [][]models.Match) []StandingResponse {
+	rows := make([]StandingResponse, 0, len(table))
+	for i, standing := range table {
+		rows = append(rows, StandingResponse{
+			Position:       i + 1,
+			Team:           newTeamRef(standing.Team),
+			Played:         standing.Played,
+			Won:            standing.Won,
+			Drawn:          standing.Drawn,
+			Lost:           standing.Lost,
+			GoalsFor:       standing.GoalsFor,
+			GoalsAgainst:   standing.GoalsAgainst,
+			GoalDifference: standing.GoalDiff,
+			Points:         standing.Points,
+			FairPlay:       standing.FairPlay,
+			Tiebreaker:     standing.Tiebreaker,
+			Form:           form(standing.Team.ID, matches),
+		})
+	}
+	return rows
+}
+
+// form returns the team's last formLength results as W, D and L, oldest
+// first.
+func form(teamID int, matches [][]models.Match) string {
+	var results []byte
+	for _, weekMatches := range matches {
+		for _, match := range weekMatches {
+			if !match.Played || (match.Home.ID != teamID && match.Away.ID != teamID) {
+				continue
+			}
+			goalsFor, goalsAgainst := match.HomeGoals, match.AwayGoals
+			if match.Away.ID == teamID {
+				goalsFor, goalsAgainst = goalsAgainst, goalsFor
+			}
+			switch {
+			case goalsFor > goalsAgainst:
+				results = append(results, 'W')
+			case goalsFor < goalsAgainst:
+				results = append(results, 'L')
+			default:
+				results = append(results, 'D')
+			}
+		}
+	}
+	if len(results) > formLength {
+		results = results[len(results)-formLength:]
+	}
+	return string(results)
+}
+
+// GetStandingsV1 returns the ranked table with positions and form.
+func (api *API) GetStandingsV1(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	snapshot := sim.Snapshot()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newStandingResponses(snapshot.Table, snapshot.Matches))
+}
+
+// MatchesV1 returns every week of fixtures with its status and bye.
+func (api *API) MatchesV1(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+
+	snapshot := sim.Snapshot()
+	weeks := make([]WeekResponse, 0, len(snapshot.Matches))
+	for i, weekMatches := range snapshot.Matches {
+		weeks = append(weeks, newWeekResponse(i+1, weekMatches, snapshot.Teams))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(weeks)
+}
+
+// GetMatchV1 returns a single fixture by ID.
+func (api *API) GetMatchV1(w http.ResponseWriter, r *http.Request) {
+	sim, ok := api.league(w, r)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["matchId"])
+	if err != nil {
+		writeError(w, fmt.Errorf("%w: invalid match ID", errInvalidParameter))
+		return
+	}
+
+	match, err := sim.GetMatchByID(id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(newMatchResponse(*match))
+}
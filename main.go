@@ -1,16 +1,22 @@
 package main
 
 import (
+    "flag"
     "log"
     "net/http"
+    "os"
 
     "github.com/gorilla/mux"
+    "league-simulator/db"
     "league-simulator/models"
     "league-simulator/services"
     "league-simulator/handlers"
 )
 
 func main() {
+    dbPath := flag.String("db", os.Getenv("LEAGUE_DB_PATH"), "SQLite database path (env LEAGUE_DB_PATH); empty keeps the league in memory")
+    flag.Parse()
+
     teams := []models.Team{
         {ID: 1, Name: "Manchester United", Strength: 5},
         {ID: 2, Name: "Manchester City", Strength: 7},
@@ -18,9 +24,29 @@ func main() {
         {ID: 4, Name: "Liverpool", Strength: 8},
     }
 
-    simulator := services.NewSimulator(teams)
+    var repo services.Repository
+    if *dbPath != "" {
+        sqlite, err := db.OpenSQLite(*dbPath)
+        if err != nil {
+            log.Fatalf("open database: %v", err)
+        }
+        defer sqlite.Close()
+        repo = sqlite
+        log.Printf("Persisting leagues to %s", *dbPath)
+    }
+
+    leagues := services.NewRegistry(repo)
+    if err := leagues.Load(); err != nil {
+        log.Fatalf("load leagues: %v", err)
+    }
+    if len(leagues.List()) == 0 {
+        if _, err := leagues.Create("Premier League", teams); err != nil {
+            log.Fatalf("create default league: %v", err)
+        }
+    }
+
     predictor := services.NewPredictor()
-    api := handlers.NewAPI(simulator, predictor)
+    api := handlers.NewAPI(leagues, predictor)
 
     r := mux.NewRouter()
     api.RegisterRoutes(r)
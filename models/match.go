@@ -8,5 +8,31 @@ type Match struct {
     AwayGoals int
     Played    bool
     Week      int
+    HalfTime  *Score       // nil when the match has no timeline (e.g. an edited result)
+    Events    []MatchEvent // In minute order
+}
+// Match represents a football match between two teams.
+
+// Score is the score of a match at some point.
+type Score struct {
+    HomeGoals int
+    AwayGoals int
+}
+
+// Match event types.
+const (
+    EventGoal         = "goal"
+    EventYellowCard   = "yellow_card"
+    EventRedCard      = "red_card"
+    EventSubstitution = "substitution"
+)
+
+// MatchEvent is something that happened during a match. Stoppage time is
+// counted in AddedTime, so 45+2 is Minute 45 and AddedTime 2.
+type MatchEvent struct {
+    Minute    int
+    AddedTime int
+    Type      string
+    TeamID    int
+    PlayerID  int // 0 for substitutions and for teams without a squad
 }
-// Match represents a football match between two teams.
\ No newline at end of file
@@ -0,0 +1,18 @@
+package models
+
+type Player struct {
+    ID       int
+    TeamID   int
+    Name     string
+    Position string // GK, DF, MF or FW
+    Rating   int    // 1-100; used to pick who scores
+}
+// Player represents a member of a team's squad.
+
+// Player positions.
+const (
+    Goalkeeper = "GK"
+    Defender   = "DF"
+    Midfielder = "MF"
+    Forward    = "FW"
+)
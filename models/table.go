@@ -10,5 +10,7 @@ type Standing struct {
     GoalsAgainst int
     GoalDiff   int
     Points     int
+    FairPlay   int    // Discipline score (deductions for cards, 0 is best)
+    Tiebreaker string // Rule that separates the team from the one above
 }
 // Standing represents the standing of a team in the league.
\ No newline at end of file
@@ -3,5 +3,7 @@ package models
 type Team struct {
     ID     int
     Name   string
-    Strength int // Tahmin algoritmasında kullanılacak
+    Strength int // Used by the prediction algorithm
+    Attack   int // Strength is used when 0
+    Defence  int // Strength is used when 0
 }
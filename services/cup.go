@@ -0,0 +1,541 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrCupNotFound is returned when no cup has the requested ID.
+	ErrCupNotFound = errors.New("cup not found")
+	// ErrCupFinished is returned by SimulateRound once the final is decided.
+	ErrCupFinished = errors.New("cup finished: no rounds left to simulate")
+	// ErrInvalidCup is returned for an unknown draw or number of legs.
+	ErrInvalidCup = errors.New("invalid cup")
+)
+
+// Draw types accepted by CupOptions.
+const (
+	// DrawSeeded keeps the entrants in the order given, first seed first.
+	// Seeds are kept apart until the late rounds and the top seeds get the
+	// byes.
+	DrawSeeded = "seeded"
+	// DrawRandom shuffles the entrants with the cup's RNG before placing
+	// them as in a seeded draw.
+	DrawRandom = "random"
+)
+
+const (
+	// extraTimeShare is the length of extra time relative to a full match.
+	extraTimeShare = 30.0 / 90.0
+	// penaltyConversion is the chance of scoring a shootout kick.
+	penaltyConversion = 0.75
+	// shootoutKicks is the number of kicks each side takes before sudden death.
+	shootoutKicks = 5
+)
+
+// CupOptions configures a knockout cup.
+type CupOptions struct {
+	Draw string
+	// Legs is 1 for single matches or 2 for home and away ties. The final is
+	// always a single match.
+	Legs int
+	// Seed defaults to the current time.
+	Seed *int64
+	// Engine defaults to a PoissonEngine. Elo engines play from the
+	// entrants' initial ratings.
+	Engine MatchEngine
+}
+
+// Tie is one pairing in the bracket. Home and Away are zero until the ties
+// feeding this one are decided; a bye has only Home. ExtraTime and
+// Penalties are oriented to Home and Away, whichever leg they came in.
+type Tie struct {
+	ID        int
+	Round     int
+	Home      models.Team
+	Away      models.Team
+	Bye       bool
+	Legs      []models.Match
+	ExtraTime *models.Score
+	Penalties *models.Score
+	WinnerID  int
+}
+
+// Aggregate returns the goals each side scored over every leg, extra time
+// included.
+func (t Tie) Aggregate() (home, away int) {
+	for _, leg := range t.Legs {
+		if leg.Home.ID == t.Home.ID {
+			home, away = home+leg.HomeGoals, away+leg.AwayGoals
+		} else {
+			home, away = home+leg.AwayGoals, away+leg.HomeGoals
+		}
+	}
+	if t.ExtraTime != nil {
+		home += t.ExtraTime.HomeGoals
+		away += t.ExtraTime.AwayGoals
+	}
+	return home, away
+}
+
+// CupRound is one round of the bracket. Each tie's winner goes to tie i/2
+// of the next round, as the home side when i is even.
+type CupRound struct {
+	Number int
+	Name   string
+	Ties   []Tie
+}
+
+// CupState is everything needed to restore a Cup.
+type CupState struct {
+	ID        int
+	Name      string
+	Teams     []models.Team
+	Draw      string
+	Legs      int
+	Seed      int64
+	Engine    string
+	Rounds    []CupRound
+	NextMatch int
+}
+
+// Cup is a single-elimination competition. It is safe for concurrent use.
+type Cup struct {
+	mu        sync.RWMutex
+	id        int
+	name      string
+	teams     []models.Team
+	draw      string
+	legs      int
+	seed      int64
+	engine    MatchEngine
+	rng       *rand.Rand
+	rounds    []CupRound
+	nextMatch int
+	repo      Repository
+}
+
+// NewCup draws a bracket for teams. With a seeded draw teams must be in seed
+// order.
+func NewCup(teams []models.Team, opts CupOptions) (*Cup, error) {
+	teams, err := normaliseTeams(teams)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newCup(teams, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.rounds = c.drawBracket()
+	return c, nil
+}
+
+// newCupFromSlots builds a cup whose first round is laid out as in slots:
+// tie i is slots[2i] against slots[2i+1], and a zero away team is a bye.
+// len(slots) must be a power of two.
+func newCupFromSlots(slots []models.Team, opts CupOptions) (*Cup, error) {
+	var teams []models.Team
+	for _, team := range slots {
+		if team.ID != 0 {
+			teams = append(teams, team)
+		}
+	}
+	c, err := newCup(teams, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.rounds = c.bracket(slots)
+	return c, nil
+}
+
+// newCup applies the defaults in opts and returns a cup without a bracket.
+func newCup(teams []models.Team, opts CupOptions) (*Cup, error) {
+	if opts.Draw == "" {
+		opts.Draw = DrawSeeded
+	}
+	if opts.Draw != DrawSeeded && opts.Draw != DrawRandom {
+		return nil, fmt.Errorf("%w: draw must be %q or %q", ErrInvalidCup, DrawSeeded, DrawRandom)
+	}
+	if opts.Legs == 0 {
+		opts.Legs = 1
+	}
+	if opts.Legs != 1 && opts.Legs != 2 {
+		return nil, fmt.Errorf("%w: ties must have 1 or 2 legs", ErrInvalidCup)
+	}
+	if opts.Engine == nil {
+		opts.Engine = NewPoissonEngine()
+	}
+	seed := time.Now().UnixNano()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+
+	return &Cup{
+		teams:     teams,
+		draw:      opts.Draw,
+		legs:      opts.Legs,
+		seed:      seed,
+		engine:    opts.Engine,
+		rng:       rand.New(rand.NewSource(seed)),
+		nextMatch: 1,
+	}, nil
+}
+
+// restoreCup rebuilds a cup from saved state. Rounds left to play are drawn
+// from the saved seed as they would have been without a restart.
+func restoreCup(state CupState) *Cup {
+	c := &Cup{
+		id:        state.ID,
+		name:      state.Name,
+		teams:     state.Teams,
+		draw:      state.Draw,
+		legs:      state.Legs,
+		seed:      state.Seed,
+		engine:    NewPoissonEngine(),
+		rounds:    state.Rounds,
+		nextMatch: state.NextMatch,
+	}
+	if engine, err := ParseEngine(state.Engine); err == nil {
+		c.engine = engine
+	}
+	return c
+}
+
+// drawBracket places the entrants into a bracket whose size is the next
+// power of two. Byes go to the top of the seeding.
+func (c *Cup) drawBracket() []CupRound {
+	entrants := append([]models.Team(nil), c.teams...)
+	if c.draw == DrawRandom {
+		c.rng.Shuffle(len(entrants), func(i, j int) {
+			entrants[i], entrants[j] = entrants[j], entrants[i]
+		})
+	}
+
+	size := 1
+	for size < len(entrants) {
+		size *= 2
+	}
+	slots := make([]models.Team, size)
+	for i, seed := range seedPositions(size) {
+		if seed < len(entrants) {
+			slots[i] = entrants[seed]
+		}
+	}
+	return c.bracket(slots)
+}
+
+// bracket builds every round from the first-round slots, leaving the later
+// rounds as empty ties, and sends the teams with a bye through.
+func (c *Cup) bracket(slots []models.Team) []CupRound {
+	var rounds []CupRound
+	tieID := 1
+	for number, ties := 1, len(slots)/2; ties >= 1; number, ties = number+1, ties/2 {
+		round := CupRound{Number: number, Name: roundName(ties), Ties: make([]Tie, ties)}
+		for i := range round.Ties {
+			round.Ties[i] = Tie{ID: tieID, Round: number}
+			tieID++
+		}
+		rounds = append(rounds, round)
+	}
+
+	first := rounds[0].Ties
+	for i := range first {
+		tie := &first[i]
+		tie.Home, tie.Away = slots[2*i], slots[2*i+1]
+		tie.Bye = tie.Away.ID == 0
+	}
+	for i := range first {
+		if first[i].Bye {
+			c.decide(rounds, 0, i, first[i].Home.ID)
+		}
+	}
+	return rounds
+}
+
+// seedPositions returns the seed, counted from 0, placed in each slot of a
+// bracket of size slots, so that seeds 0 and 1 can only meet in the final.
+func seedPositions(size int) []int {
+	positions := []int{0}
+	for len(positions) < size {
+		n := len(positions) * 2
+		next := make([]int, 0, n)
+		for _, seed := range positions {
+			next = append(next, seed, n-1-seed)
+		}
+		positions = next
+	}
+	return positions
+}
+
+func roundName(ties int) string {
+	switch ties {
+	case 1:
+		return "Final"
+	case 2:
+		return "Semi-finals"
+	case 4:
+		return "Quarter-finals"
+	}
+	return fmt.Sprintf("Round of %d", ties*2)
+}
+
+// decide records the winner of tie i in round r and moves them into the
+// next round.
+func (c *Cup) decide(rounds []CupRound, r, i, winnerID int) {
+	tie := &rounds[r].Ties[i]
+	tie.WinnerID = winnerID
+	if r+1 == len(rounds) {
+		return
+	}
+	winner := tie.Home
+	if winnerID == tie.Away.ID {
+		winner = tie.Away
+	}
+	next := &rounds[r+1].Ties[i/2]
+	if i%2 == 0 {
+		next.Home = winner
+	} else {
+		next.Away = winner
+	}
+}
+
+// SimulateRound plays every tie of the earliest undecided round, or returns
+// ErrCupFinished once the final has been decided.
+func (c *Cup) SimulateRound() (CupRound, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	engine := c.engine
+	if rated, ok := engine.(RatedEngine); ok {
+		engine = rated.WithRatings(NewEloSystem().InitialRatings(c.teams))
+	}
+	for r := range c.rounds {
+		round := &c.rounds[r]
+		if round.Decided() {
+			continue
+		}
+		c.rng = rand.New(rand.NewSource(deriveSeed(c.seed, round.Number)))
+		for i := range round.Ties {
+			if round.Ties[i].WinnerID == 0 {
+				c.playTie(engine, &round.Ties[i])
+				c.decide(c.rounds, r, i, round.Ties[i].WinnerID)
+			}
+		}
+		c.persist()
+		return copyRound(*round), nil
+	}
+	return CupRound{}, ErrCupFinished
+}
+
+// Decided reports whether every tie in the round has a winner.
+func (round CupRound) Decided() bool {
+	for _, tie := range round.Ties {
+		if tie.WinnerID == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// playTie plays the legs of a tie, then extra time and penalties while the
+// aggregate is level. The final is a single match however many legs the
+// other ties have. Callers must hold mu.
+func (c *Cup) playTie(engine MatchEngine, tie *Tie) {
+	legs := c.legs
+	if tie.Round == len(c.rounds) {
+		legs = 1
+	}
+	for leg := 0; leg < legs; leg++ {
+		match := models.Match{ID: c.nextMatch, Home: tie.Home, Away: tie.Away, Week: tie.Round}
+		if leg == 1 {
+			match.Home, match.Away = tie.Away, tie.Home
+		}
+		c.nextMatch++
+		playMatch(engine, match, c.rng).apply(&match)
+		tie.Legs = append(tie.Legs, match)
+	}
+
+	home, away := tie.Aggregate()
+	if home == away {
+		// Extra time is played at the ground of the last leg
+		last := tie.Legs[len(tie.Legs)-1]
+		extraHome, extraAway := extraTime(engine, last.Home, last.Away, c.rng)
+		if last.Home.ID != tie.Home.ID {
+			extraHome, extraAway = extraAway, extraHome
+		}
+		tie.ExtraTime = &models.Score{HomeGoals: extraHome, AwayGoals: extraAway}
+		home, away = tie.Aggregate()
+	}
+	if home == away {
+		penHome, penAway := penaltyShootout(c.rng)
+		tie.Penalties = &models.Score{HomeGoals: penHome, AwayGoals: penAway}
+		home, away = penHome, penAway
+	}
+
+	tie.WinnerID = tie.Home.ID
+	if away > home {
+		tie.WinnerID = tie.Away.ID
+	}
+}
+
+// extraTime plays 30 minutes by playing a full match and keeping each goal
+// with probability extraTimeShare, which for goals arriving at a steady
+// rate is the same as playing a third of the match.
+func extraTime(engine MatchEngine, home, away models.Team, rng *rand.Rand) (int, int) {
+	homeGoals, awayGoals := engine.PlayMatch(home, away, rng)
+	return thin(homeGoals, rng), thin(awayGoals, rng)
+}
+
+func thin(goals int, rng *rand.Rand) int {
+	kept := 0
+	for i := 0; i < goals; i++ {
+		if rng.Float64() < extraTimeShare {
+			kept++
+		}
+	}
+	return kept
+}
+
+// penaltyShootout takes alternate kicks, home first, until one side cannot
+// be caught, then goes to sudden death.
+func penaltyShootout(rng *rand.Rand) (home, away int) {
+	decided := func(homeLeft, awayLeft int) bool {
+		return home > away+awayLeft || away > home+homeLeft
+	}
+	for kick := 0; kick < shootoutKicks; kick++ {
+		if rng.Float64() < penaltyConversion {
+			home++
+		}
+		if decided(shootoutKicks-kick-1, shootoutKicks-kick) {
+			return home, away
+		}
+		if rng.Float64() < penaltyConversion {
+			away++
+		}
+		if decided(shootoutKicks-kick-1, shootoutKicks-kick-1) {
+			return home, away
+		}
+	}
+	for home == away {
+		if rng.Float64() < penaltyConversion {
+			home++
+		}
+		if rng.Float64() < penaltyConversion {
+			away++
+		}
+	}
+	return home, away
+}
+
+// Bracket returns a copy of every round.
+func (c *Cup) Bracket() []CupRound {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	rounds := make([]CupRound, len(c.rounds))
+	for i, round := range c.rounds {
+		rounds[i] = copyRound(round)
+	}
+	return rounds
+}
+
+func copyRound(round CupRound) CupRound {
+	round.Ties = append([]Tie(nil), round.Ties...)
+	for i := range round.Ties {
+		round.Ties[i].Legs = append([]models.Match(nil), round.Ties[i].Legs...)
+	}
+	return round
+}
+
+// Champion returns the winner of the final, if it has been played.
+func (c *Cup) Champion() (models.Team, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	final := c.rounds[len(c.rounds)-1].Ties[0]
+	if final.WinnerID == 0 {
+		return models.Team{}, false
+	}
+	if final.WinnerID == final.Away.ID {
+		return final.Away, true
+	}
+	return final.Home, true
+}
+
+// ID returns the cup's registry ID.
+func (c *Cup) ID() int {
+	return c.id
+}
+
+// Name returns the cup's display name.
+func (c *Cup) Name() string {
+	return c.name
+}
+
+// Teams returns the entrants in the order they were given.
+func (c *Cup) Teams() []models.Team {
+	return append([]models.Team(nil), c.teams...)
+}
+
+// Draw returns the draw type. Like the legs and engine it is fixed at
+// construction time.
+func (c *Cup) Draw() string {
+	return c.draw
+}
+
+// Legs returns the number of legs per tie.
+func (c *Cup) Legs() int {
+	return c.legs
+}
+
+// Seed returns the seed the cup's draw and matches come from.
+func (c *Cup) Seed() int64 {
+	return c.seed
+}
+
+// Engine returns the match engine the cup plays with.
+func (c *Cup) Engine() MatchEngine {
+	return c.engine
+}
+
+// state copies the cup into a CupState. Callers must hold mu.
+func (c *Cup) state() CupState {
+	rounds := make([]CupRound, len(c.rounds))
+	for i, round := range c.rounds {
+		rounds[i] = copyRound(round)
+	}
+	return CupState{
+		ID:        c.id,
+		Name:      c.name,
+		Teams:     append([]models.Team(nil), c.teams...),
+		Draw:      c.draw,
+		Legs:      c.legs,
+		Seed:      c.seed,
+		Engine:    EngineName(c.engine),
+		Rounds:    rounds,
+		NextMatch: c.nextMatch,
+	}
+}
+
+// persist writes the cup through to the repository, if one is configured.
+// Callers must hold mu.
+func (c *Cup) persist() {
+	if c.repo == nil {
+		return
+	}
+	if err := c.repo.SaveCup(c.state()); err != nil {
+		log.Printf("failed to persist cup %d: %v", c.id, err)
+	}
+}
+
+// detach stops the cup writing through to its repository.
+func (c *Cup) detach() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.repo = nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"league-simulator/models"
+)
+
+const (
+	// DefaultInitialRating is the Elo rating of a team of average strength.
+	DefaultInitialRating = 1500.0
+	// DefaultKFactor scales how far a single result moves both ratings.
+	DefaultKFactor = 20.0
+	// DefaultEloHomeAdvantage is added to the home side's rating when
+	// predicting a result.
+	DefaultEloHomeAdvantage = 100.0
+)
+
+// EloSystem holds the parameters of the rating model. A league's ratings are
+// derived from its teams and results by Replay, so edits and undo never leave
+// them out of step with the table.
+type EloSystem struct {
+	Initial       float64
+	K             float64
+	HomeAdvantage float64
+}
+
+// NewEloSystem returns an EloSystem with the default parameters.
+func NewEloSystem() *EloSystem {
+	return &EloSystem{
+		Initial:       DefaultInitialRating,
+		K:             DefaultKFactor,
+		HomeAdvantage: DefaultEloHomeAdvantage,
+	}
+}
+
+// Expected returns the home side's expected score, between 0 and 1, where a
+// draw counts as half a win.
+func (e *EloSystem) Expected(home, away float64) float64 {
+	return 1 / (1 + math.Pow(10, (away-home-e.HomeAdvantage)/400))
+}
+
+// Update returns both ratings after a match. Wins by two or more goals move
+// the ratings further, as in the World Football Elo ratings.
+func (e *EloSystem) Update(home, away float64, homeGoals, awayGoals int) (float64, float64) {
+	score := 0.5
+	switch {
+	case homeGoals > awayGoals:
+		score = 1
+	case homeGoals < awayGoals:
+		score = 0
+	}
+	delta := e.K * goalDifferenceMultiplier(homeGoals-awayGoals) * (score - e.Expected(home, away))
+	return home + delta, away - delta
+}
+
+func goalDifferenceMultiplier(diff int) float64 {
+	if diff < 0 {
+		diff = -diff
+	}
+	switch {
+	case diff <= 1:
+		return 1
+	case diff == 2:
+		return 1.5
+	default:
+		return (11 + float64(diff)) / 8
+	}
+}
+
+// InitialRatings seeds every team from its Strength relative to the league
+// average. A team twice as strong as average starts 400*log10(2) ≈ 120
+// points higher, matching the odds the Poisson engine gives it.
+func (e *EloSystem) InitialRatings(teams []models.Team) map[int]float64 {
+	ratings := make(map[int]float64, len(teams))
+	if len(teams) == 0 {
+		return ratings
+	}
+	mean := 0.0
+	for _, team := range teams {
+		mean += strengthRating(team)
+	}
+	mean /= float64(len(teams))
+	for _, team := range teams {
+		ratings[team.ID] = e.Initial + 400*math.Log10(strengthRating(team)/mean)
+	}
+	return ratings
+}
+
+func strengthRating(team models.Team) float64 {
+	return math.Max(float64(team.Strength), 1)
+}
+
+// Replay rates every played match in week order. The result holds the
+// ratings before week 1 at index 0 and after week n at index n, up to the
+// last week with a played match.
+func (e *EloSystem) Replay(teams []models.Team, matches [][]models.Match) []map[int]float64 {
+	ratings := e.InitialRatings(teams)
+	history := []map[int]float64{copyRatings(ratings)}
+
+	lastPlayed := 0
+	for weekIdx, weekMatches := range matches {
+		for _, match := range weekMatches {
+			if match.Played {
+				lastPlayed = weekIdx + 1
+			}
+		}
+	}
+	for _, weekMatches := range matches[:lastPlayed] {
+		for _, match := range weekMatches {
+			if !match.Played {
+				continue
+			}
+			ratings[match.Home.ID], ratings[match.Away.ID] = e.Update(
+				ratings[match.Home.ID], ratings[match.Away.ID], match.HomeGoals, match.AwayGoals)
+		}
+		history = append(history, copyRatings(ratings))
+	}
+	return history
+}
+
+func copyRatings(ratings map[int]float64) map[int]float64 {
+	copied := make(map[int]float64, len(ratings))
+	for id, rating := range ratings {
+		copied[id] = rating
+	}
+	return copied
+}
+
+// RatedEngine is a MatchEngine that plays from Elo ratings. WithRatings binds
+// the ratings current at kick-off and returns the engine to use; the
+// simulator does this before every week and the predictor before a forecast.
+type RatedEngine interface {
+	MatchEngine
+	WithRatings(ratings map[int]float64) MatchEngine
+}
+
+// EloEngine splits the expected goals of a match by the sides' Elo expected
+// scores, then draws each side's goals from a Poisson distribution. Teams
+// without a bound rating are treated as average.
+type EloEngine struct {
+	Elo *EloSystem
+	// BaseGoals is the expected number of goals per side in an even match.
+	BaseGoals float64
+	ratings   map[int]float64
+}
+
+// NewEloEngine returns an EloEngine with the default parameters.
+func NewEloEngine() *EloEngine {
+	return &EloEngine{Elo: NewEloSystem(), BaseGoals: DefaultBaseGoals}
+}
+
+// WithRatings returns a copy of the engine that plays from ratings.
+func (e *EloEngine) WithRatings(ratings map[int]float64) MatchEngine {
+	bound := *e
+	bound.ratings = ratings
+	return &bound
+}
+
+func (e *EloEngine) rating(team models.Team) float64 {
+	if rating, ok := e.ratings[team.ID]; ok {
+		return rating
+	}
+	return e.Elo.Initial
+}
+
+// ExpectedGoals returns the mean goals for the home and away sides.
+func (e *EloEngine) ExpectedGoals(home, away models.Team) (float64, float64) {
+	p := e.Elo.Expected(e.rating(home), e.rating(away))
+	total := 2 * e.BaseGoals
+	return math.Max(total*p, 0.05), math.Max(total*(1-p), 0.05)
+}
+
+// PlayMatch simulates a match and returns the goals scored by each side.
+func (e *EloEngine) PlayMatch(home, away models.Team, rng *rand.Rand) (int, int) {
+	homeXG, awayXG := e.ExpectedGoals(home, away)
+	return poisson(rng, homeXG), poisson(rng, awayXG)
+}
+
+// ScorelineProbability returns the probability of the match ending
+// homeGoals-awayGoals.
+func (e *EloEngine) ScorelineProbability(home, away models.Team, homeGoals, awayGoals int) float64 {
+	homeXG, awayXG := e.ExpectedGoals(home, away)
+	return poissonPMF(homeXG, homeGoals) * poissonPMF(awayXG, awayGoals)
+}
+
+// ErrUnknownEngine is returned for an engine name ParseEngine does not know.
+var ErrUnknownEngine = errors.New("unknown match engine")
+
+// Engine names accepted by ParseEngine.
+const (
+	EnginePoisson = "poisson"
+	EngineElo     = "elo"
+)
+
+// ParseEngine returns a new engine with default parameters by name.
+func ParseEngine(name string) (MatchEngine, error) {
+	switch name {
+	case EnginePoisson:
+		return NewPoissonEngine(), nil
+	case EngineElo:
+		return NewEloEngine(), nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnknownEngine, name)
+}
+
+// EngineName is the inverse of ParseEngine. Engines it does not know are
+// reported as "custom" and are not restored from storage.
+func EngineName(engine MatchEngine) string {
+	switch engine.(type) {
+	case *PoissonEngine:
+		return EnginePoisson
+	case *EloEngine:
+		return EngineElo
+	}
+	return "custom"
+}
+
+// WithElo sets the parameters used to rate the league's teams.
+func WithElo(elo *EloSystem) SimulatorOption {
+	return func(s *SimulatorImpl) {
+		s.elo = elo
+	}
+}
+
+// Elo returns the league's rating parameters. Like the engine they are fixed
+// at construction time.
+func (s *SimulatorImpl) Elo() *EloSystem {
+	return s.elo
+}
+
+// Ratings returns every team's current Elo rating.
+func (s *SimulatorImpl) Ratings() map[int]float64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentRatings()
+}
+
+// RatingHistory is a consistent copy of a league's teams and the ratings
+// they have had.
+type RatingHistory struct {
+	Teams []models.Team
+	// Weeks holds the ratings before week 1 at index 0 and after each played
+	// week n at index n.
+	Weeks []map[int]float64
+}
+
+// RatingHistory returns the teams and their ratings week by week, read
+// together so every rating belongs to a listed team.
+func (s *SimulatorImpl) RatingHistory() RatingHistory {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	weeks := make([]map[int]float64, len(s.ratings))
+	for i, ratings := range s.ratings {
+		weeks[i] = copyRatings(ratings)
+	}
+	return RatingHistory{Teams: append([]models.Team(nil), s.teams...), Weeks: weeks}
+}
+
+// currentRatings returns a copy of the latest ratings. Callers must hold mu.
+func (s *SimulatorImpl) currentRatings() map[int]float64 {
+	return copyRatings(s.ratings[len(s.ratings)-1])
+}
+
+// ratingsBefore returns a copy of the ratings going into week (1-based),
+// built only from the results of earlier weeks. Results already entered for
+// that week or later ones, e.g. by an edit, do not leak into it. Callers must
+// hold mu.
+func (s *SimulatorImpl) ratingsBefore(week int) map[int]float64 {
+	return copyRatings(s.ratings[min(week-1, len(s.ratings)-1)])
+}
+
+// recalculateRatings rebuilds the rating history from the fixtures. It runs
+// after every change to results or team strengths. Callers must hold mu.
+func (s *SimulatorImpl) recalculateRatings() {
+	s.ratings = s.elo.Replay(s.teams, s.matches)
+}
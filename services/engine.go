@@ -0,0 +1,96 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+
+	"league-simulator/models"
+)
+
+// MatchEngine decides the final score of a single match. All randomness must
+// come from rng so that seeded simulations can be replayed.
+type MatchEngine interface {
+	PlayMatch(home, away models.Team, rng *rand.Rand) (homeGoals, awayGoals int)
+}
+
+// ScorelineModel is implemented by engines that can report the exact
+// probability of a scoreline without sampling.
+type ScorelineModel interface {
+	ScorelineProbability(home, away models.Team, homeGoals, awayGoals int) float64
+}
+
+const (
+	// DefaultBaseGoals is the expected number of goals for an average side
+	// facing an average opponent on neutral ground.
+	DefaultBaseGoals = 1.35
+	// DefaultHomeAdvantage multiplies the home side's expected goals.
+	DefaultHomeAdvantage = 1.25
+)
+
+// PoissonEngine draws each side's goals from a Poisson distribution whose
+// mean comes from the attacking team's attack rating against the defending
+// team's defence rating.
+type PoissonEngine struct {
+	BaseGoals     float64
+	HomeAdvantage float64
+}
+
+// NewPoissonEngine returns a PoissonEngine with the default parameters.
+func NewPoissonEngine() *PoissonEngine {
+	return &PoissonEngine{
+		BaseGoals:     DefaultBaseGoals,
+		HomeAdvantage: DefaultHomeAdvantage,
+	}
+}
+
+// ExpectedGoals returns the mean goals for the home and away sides.
+func (e *PoissonEngine) ExpectedGoals(home, away models.Team) (float64, float64) {
+	homeXG := e.BaseGoals * e.HomeAdvantage * attackRating(home) / defenceRating(away)
+	awayXG := e.BaseGoals * attackRating(away) / defenceRating(home)
+	return homeXG, awayXG
+}
+
+// PlayMatch simulates a match and returns the goals scored by each side.
+func (e *PoissonEngine) PlayMatch(home, away models.Team, rng *rand.Rand) (int, int) {
+	homeXG, awayXG := e.ExpectedGoals(home, away)
+	return poisson(rng, homeXG), poisson(rng, awayXG)
+}
+
+// ScorelineProbability returns the probability of the match ending
+// homeGoals-awayGoals, treating both sides' goals as independent.
+func (e *PoissonEngine) ScorelineProbability(home, away models.Team, homeGoals, awayGoals int) float64 {
+	homeXG, awayXG := e.ExpectedGoals(home, away)
+	return poissonPMF(homeXG, homeGoals) * poissonPMF(awayXG, awayGoals)
+}
+
+// poissonPMF returns P(X = k) for X ~ Poisson(lambda).
+func poissonPMF(lambda float64, k int) float64 {
+	lgamma, _ := math.Lgamma(float64(k + 1))
+	return math.Exp(float64(k)*math.Log(lambda) - lambda - lgamma)
+}
+
+// poisson samples a Poisson distributed value using Knuth's algorithm.
+func poisson(rng *rand.Rand, lambda float64) int {
+	limit := math.Exp(-lambda)
+	k := 0
+	p := rng.Float64()
+	for p > limit {
+		k++
+		p *= rng.Float64()
+	}
+	return k
+}
+
+func attackRating(team models.Team) float64 {
+	if team.Attack > 0 {
+		return float64(team.Attack)
+	}
+	return math.Max(float64(team.Strength), 1)
+}
+
+func defenceRating(team models.Team) float64 {
+	if team.Defence > 0 {
+		return float64(team.Defence)
+	}
+	return math.Max(float64(team.Strength), 1)
+}
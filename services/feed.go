@@ -0,0 +1,241 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"league-simulator/models"
+)
+
+// FeedEventType names an event published on the live feed.
+type FeedEventType string
+
+const (
+	FeedMatchPlayed      FeedEventType = "MatchPlayed"
+	FeedWeekCompleted    FeedEventType = "WeekCompleted"
+	FeedStandingsChanged FeedEventType = "StandingsChanged"
+	FeedMatchEdited      FeedEventType = "MatchEdited"
+	// FeedResync stands in for events a subscriber can no longer be sent,
+	// because the backlog no longer reaches back to the ID it resumed from
+	// or because it fell behind. Its ID is the newest event it replaces;
+	// the subscriber should reload the league state instead of replaying.
+	FeedResync FeedEventType = "Resync"
+)
+
+const (
+	// DefaultFeedBacklog is how many recent events a Feed keeps per league
+	// for subscribers resuming after a disconnect.
+	DefaultFeedBacklog = 256
+	// subscriberBuffer is how many events a subscriber may fall behind
+	// before its unread events are replaced by a FeedResync.
+	subscriberBuffer = 64
+)
+
+// FeedEvent is a change to a league as it happens. Which fields are set
+// depends on Type.
+type FeedEvent struct {
+	// ID increases by one with every event published on the feed, across
+	// all leagues. The first ID of a feed is taken from the clock, so IDs
+	// keep increasing across server restarts.
+	ID       int64
+	LeagueID int
+	Type     FeedEventType
+	Time     time.Time
+	// Match is the match a MatchPlayed or MatchEdited event is about.
+	Match *models.Match
+	// Week and Results describe a WeekCompleted event.
+	Week    int
+	Results []MatchResult
+	// Table and Matches describe a StandingsChanged event; Matches gives
+	// each team's form. They are only filled in while the league has
+	// subscribers and are not kept in the backlog, so a replayed event, or
+	// one published before anyone subscribed, has neither and stands for the
+	// league's current table.
+	Table   []models.Standing
+	Matches [][]models.Match
+}
+
+// Feed fans league events out to any number of subscribers. It keeps the
+// last few events of each league so a subscriber can resume from the ID it
+// last saw. Publishing never blocks: a subscriber that falls too far behind
+// has its unread events replaced by a FeedResync.
+type Feed struct {
+	mu sync.Mutex
+	// firstID is the ID of the first event published by this feed; IDs
+	// below it were given out before the server restarted.
+	firstID     int64
+	nextID      int64
+	backlogs    map[int]*leagueBacklog
+	size        int
+	subscribers map[*subscription]struct{}
+}
+
+// leagueBacklog is the kept events of one league, oldest first.
+type leagueBacklog struct {
+	events []FeedEvent
+	// dropped is the ID of the newest event pushed out of events, or 0.
+	dropped int64
+}
+
+type subscription struct {
+	leagueID int
+	events   chan FeedEvent
+}
+
+// NewFeed returns a Feed that keeps the last backlog events of each league.
+// Its IDs start at the current time in microseconds, which is above any ID
+// an earlier run could have reached.
+func NewFeed(backlog int) *Feed {
+	first := time.Now().UnixMicro()
+	return &Feed{
+		firstID:     first,
+		nextID:      first,
+		backlogs:    make(map[int]*leagueBacklog),
+		size:        backlog,
+		subscribers: make(map[*subscription]struct{}),
+	}
+}
+
+// Publish assigns the event its ID and time and delivers it to every
+// matching subscriber.
+func (f *Feed) Publish(event FeedEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	event.ID = f.nextID
+	f.nextID++
+	event.Time = time.Now().UTC()
+	backlog := f.backlogs[event.LeagueID]
+	if backlog == nil {
+		backlog = &leagueBacklog{}
+		f.backlogs[event.LeagueID] = backlog
+	}
+	kept := event
+	kept.Table, kept.Matches = nil, nil
+	backlog.events = append(backlog.events, kept)
+	if over := len(backlog.events) - f.size; over > 0 {
+		backlog.dropped = backlog.events[over-1].ID
+		backlog.events = backlog.events[over:]
+	}
+
+	for sub := range f.subscribers {
+		if sub.leagueID != 0 && sub.leagueID != event.LeagueID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Replace whatever the subscriber has not read, a large
+			// SimulateAll for instance, with a single resync.
+		drain:
+			for {
+				select {
+				case <-sub.events:
+				default:
+					break drain
+				}
+			}
+			sub.events <- FeedEvent{ID: event.ID, LeagueID: sub.leagueID, Type: FeedResync, Time: event.Time}
+		}
+	}
+}
+
+// HasSubscribers reports whether anyone follows the league, either directly
+// or through the all-leagues stream, so publishers can skip building
+// payloads nobody reads.
+func (f *Feed) HasSubscribers(leagueID int) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for sub := range f.subscribers {
+		if sub.leagueID == 0 || sub.leagueID == leagueID {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscribe returns the kept events after lastID followed by a channel of
+// new ones, with nothing missed or repeated in between. When events after
+// lastID have already left the backlog, or lastID is not one this feed
+// gave out because the server has restarted since, missed is a single
+// FeedResync instead of a partial or wrong replay. A lastID of 0 replays
+// the whole backlog, a negative one skips it, and leagueID 0 follows every
+// league. The channel is closed when cancel is
+// called.
+func (f *Feed) Subscribe(leagueID int, lastID int64) (missed []FeedEvent, events <-chan FeedEvent, cancel func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if lastID >= 0 {
+		missed = f.missed(leagueID, lastID)
+	}
+
+	sub := &subscription{leagueID: leagueID, events: make(chan FeedEvent, subscriberBuffer)}
+	f.subscribers[sub] = struct{}{}
+	cancel = func() {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if _, ok := f.subscribers[sub]; ok {
+			delete(f.subscribers, sub)
+			close(sub.events)
+		}
+	}
+	return missed, sub.events, cancel
+}
+
+// missed returns the kept events of the league, or of every league for
+// leagueID 0, after lastID in ID order. Callers must hold mu.
+func (f *Feed) missed(leagueID int, lastID int64) []FeedEvent {
+	resync := []FeedEvent{{ID: f.nextID - 1, LeagueID: leagueID, Type: FeedResync, Time: time.Now().UTC()}}
+	if lastID > 0 && (lastID < f.firstID-1 || lastID >= f.nextID) {
+		return resync
+	}
+	var missed []FeedEvent
+	for id, backlog := range f.backlogs {
+		if leagueID != 0 && id != leagueID {
+			continue
+		}
+		if backlog.dropped > lastID {
+			return resync
+		}
+		for _, event := range backlog.events {
+			if event.ID > lastID {
+				missed = append(missed, event)
+			}
+		}
+	}
+	sort.Slice(missed, func(i, j int) bool { return missed[i].ID < missed[j].ID })
+	return missed
+}
+
+// publish sends an event for this league to its feed, if it has one.
+// Callers must hold mu.
+func (s *SimulatorImpl) publish(event FeedEvent) {
+	if s.feed == nil {
+		return
+	}
+	event.LeagueID = s.id
+	s.feed.Publish(event)
+}
+
+// publishEdit announces an edited or cleared result and the table it leaves.
+// Callers must hold mu.
+func (s *SimulatorImpl) publishEdit(match models.Match) {
+	s.publish(FeedEvent{Type: FeedMatchEdited, Match: &match})
+	s.publishStandings()
+}
+
+// publishStandings announces the current table. The table and matches are
+// only copied while someone is subscribed. Callers must hold mu.
+func (s *SimulatorImpl) publishStandings() {
+	if s.feed == nil {
+		return
+	}
+	event := FeedEvent{Type: FeedStandingsChanged}
+	if s.feed.HasSubscribers(s.id) {
+		event.Table, event.Matches = s.sortedStandings(), s.matchesCopy()
+	}
+	s.publish(event)
+}
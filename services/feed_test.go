@@ -0,0 +1,136 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFeedKeepsABacklogPerLeague(t *testing.T) {
+	feed := NewFeed(3)
+	base := feed.nextID - 1 // events below are numbered base+1, base+2, ...
+	for i := 0; i < 10; i++ {
+		feed.Publish(FeedEvent{LeagueID: 1, Type: FeedMatchPlayed})
+	}
+	feed.Publish(FeedEvent{LeagueID: 2, Type: FeedMatchPlayed}) // base+11
+
+	// League 1's flood does not push league 2's event out.
+	missed, _, cancel := feed.Subscribe(2, 0)
+	cancel()
+	if len(missed) != 1 || missed[0].ID != base+11 {
+		t.Fatalf("league 2 missed %+v, want event %d", missed, base+11)
+	}
+
+	// Events 8-10 are kept for league 1, so resuming from 7 replays them.
+	missed, _, cancel = feed.Subscribe(1, base+7)
+	cancel()
+	if len(missed) != 3 || missed[0].ID != base+8 || missed[2].ID != base+10 {
+		t.Fatalf("resuming league 1 from 7 replayed %+v", missed)
+	}
+
+	// Resuming from 6 would skip event 7, so the subscriber resyncs.
+	missed, _, cancel = feed.Subscribe(1, base+6)
+	cancel()
+	if len(missed) != 1 || missed[0].Type != FeedResync || missed[0].ID != base+11 {
+		t.Fatalf("resuming league 1 from 6 gave %+v, want a resync at 11", missed)
+	}
+
+	// Every league's stream resyncs too, and replays in ID order otherwise.
+	if missed, _, cancel = feed.Subscribe(0, base+6); missed[0].Type != FeedResync {
+		t.Errorf("resuming all leagues from 6 gave %+v, want a resync", missed)
+	}
+	cancel()
+	missed, _, cancel = feed.Subscribe(0, base+9)
+	cancel()
+	if len(missed) != 2 || missed[0].ID != base+10 || missed[1].ID != base+11 {
+		t.Errorf("resuming all leagues from 9 replayed %+v", missed)
+	}
+}
+
+func TestFeedResyncsIDsFromAnotherRun(t *testing.T) {
+	before := NewFeed(DefaultFeedBacklog)
+	for i := 0; i < 5; i++ {
+		before.Publish(FeedEvent{LeagueID: 1, Type: FeedMatchPlayed})
+	}
+	lastSeen := before.nextID - 1
+	time.Sleep(time.Millisecond) // the restart takes longer than 5 events
+
+	// A restarted server starts above every ID the old one gave out, and a
+	// client resuming from an old ID resyncs rather than replaying events
+	// that happen to share its numbers.
+	after := NewFeed(DefaultFeedBacklog)
+	for i := 0; i < 10; i++ {
+		after.Publish(FeedEvent{LeagueID: 1, Type: FeedMatchPlayed})
+	}
+	if after.firstID <= lastSeen {
+		t.Fatalf("restarted feed starts at %d, not above the old %d", after.firstID, lastSeen)
+	}
+	for _, id := range []int64{lastSeen, 3, after.nextID + 100} {
+		missed, _, cancel := after.Subscribe(1, id)
+		cancel()
+		if len(missed) != 1 || missed[0].Type != FeedResync {
+			t.Errorf("resuming from %d gave %+v, want a resync", id, missed)
+		}
+	}
+	missed, _, cancel := after.Subscribe(1, 0)
+	cancel()
+	if len(missed) != 10 {
+		t.Errorf("replaying from 0 gave %d events, want 10", len(missed))
+	}
+}
+
+func TestFeedBuildsStandingsOnlyForSubscribers(t *testing.T) {
+	feed := NewFeed(DefaultFeedBacklog)
+	s := newSimulator(testTeams(4), WithSeed(1))
+	s.id, s.feed = 1, feed
+
+	s.SimulateWeek()
+	missed, events, cancel := feed.Subscribe(1, 0)
+	defer cancel()
+	for _, event := range missed {
+		if event.Table != nil || event.Matches != nil {
+			t.Fatalf("backlog keeps a %s event with a table", event.Type)
+		}
+	}
+
+	s.SimulateWeek()
+	var live *FeedEvent
+	for len(events) > 0 {
+		if event := <-events; event.Type == FeedStandingsChanged {
+			live = &event
+		}
+	}
+	if live == nil || len(live.Table) != 4 {
+		t.Fatalf("subscriber got standings %+v, want the table", live)
+	}
+	replayed, _, cancelReplay := feed.Subscribe(1, 0)
+	cancelReplay()
+	for _, event := range replayed {
+		if event.Table != nil {
+			t.Fatalf("backlog keeps a %s event with a table", event.Type)
+		}
+	}
+}
+
+func TestFeedResyncsASubscriberThatFallsBehind(t *testing.T) {
+	feed := NewFeed(DefaultFeedBacklog)
+	base := feed.nextID - 1
+	_, events, cancel := feed.Subscribe(1, -1)
+	defer cancel()
+
+	for i := 0; i < subscriberBuffer+10; i++ {
+		feed.Publish(FeedEvent{LeagueID: 1, Type: FeedMatchPlayed})
+	}
+
+	// The overflow replaced the unread events; the stream stays open and
+	// carries on after the resync.
+	var got []FeedEvent
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if len(got) == 0 || got[0].Type != FeedResync || got[0].ID != base+subscriberBuffer+1 {
+		t.Fatalf("first event after falling behind is %+v, want a resync at %d", got, base+subscriberBuffer+1)
+	}
+	if last := got[len(got)-1]; last.ID != base+subscriberBuffer+10 {
+		t.Errorf("stream ends at %d, want %d", last.ID, base+subscriberBuffer+10)
+	}
+}
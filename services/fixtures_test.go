@@ -0,0 +1,78 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestGenerateFixtures(t *testing.T) {
+	type pair struct{ home, away int }
+
+	for teams := 3; teams <= 24; teams++ {
+		t.Run(fmt.Sprintf("%d teams", teams), func(t *testing.T) {
+			league := testTeams(teams)
+			fixtures := generateFixtures(league)
+
+			weeks := 2 * (teams - 1)
+			if teams%2 != 0 {
+				weeks = 2 * teams
+			}
+			if len(fixtures) != weeks {
+				t.Fatalf("got %d weeks, want %d", len(fixtures), weeks)
+			}
+
+			meetings := make(map[pair]int)
+			byes := make(map[pair]int) // team and half of the season
+			ids := make(map[int]bool)
+			for w, weekMatches := range fixtures {
+				playing := make(map[int]bool)
+				for _, match := range weekMatches {
+					if match.Week != w+1 {
+						t.Errorf("match %d is in week %d but says week %d", match.ID, w+1, match.Week)
+					}
+					if ids[match.ID] {
+						t.Errorf("match ID %d used twice", match.ID)
+					}
+					ids[match.ID] = true
+					for _, id := range []int{match.Home.ID, match.Away.ID} {
+						if playing[id] {
+							t.Errorf("team %d plays twice in week %d", id, w+1)
+						}
+						playing[id] = true
+					}
+					meetings[pair{match.Home.ID, match.Away.ID}]++
+				}
+
+				resting := RestingTeams(league, weekMatches)
+				switch {
+				case teams%2 == 0 && len(resting) != 0:
+					t.Errorf("week %d: %d teams rest in an even league", w+1, len(resting))
+				case teams%2 != 0 && len(resting) != 1:
+					t.Errorf("week %d: %d teams rest, want 1", w+1, len(resting))
+				}
+				for _, team := range resting {
+					byes[pair{team.ID, 2 * w / weeks}]++
+				}
+			}
+
+			for _, home := range league {
+				for _, away := range league {
+					if home.ID == away.ID {
+						continue
+					}
+					if n := meetings[pair{home.ID, away.ID}]; n != 1 {
+						t.Errorf("team %d hosts team %d %d times", home.ID, away.ID, n)
+					}
+				}
+				for half := 0; half < 2 && teams%2 != 0; half++ {
+					if n := byes[pair{home.ID, half}]; n != 1 {
+						t.Errorf("team %d has %d byes in half %d, want 1", home.ID, n, half+1)
+					}
+				}
+			}
+			if len(ids) != teams*(teams-1) {
+				t.Errorf("got %d matches, want %d", len(ids), teams*(teams-1))
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"league-simulator/models"
+)
+
+const (
+	// DefaultFormWindow is how many recent matches count towards form.
+	DefaultFormWindow = 6
+	// DefaultFormDecay is the weight of each match relative to the one
+	// played after it.
+	DefaultFormDecay = 0.8
+	// DefaultFormWeight is how strongly a full window of form moves a side's
+	// expected goals away from its strength or rating.
+	DefaultFormWeight = 0.5
+)
+
+// GoalModel is implemented by engines that derive scores from the expected
+// goals of each side. Form can only adjust engines that implement it.
+type GoalModel interface {
+	ExpectedGoals(home, away models.Team) (float64, float64)
+}
+
+// FormModel rates each team's recent results. The last Window matches are
+// weighted by Decay per match going back in time, and goals are measured
+// against the league average for the venue, so a home win counts for less
+// than the same win away.
+type FormModel struct {
+	Window int
+	Decay  float64
+	Weight float64
+}
+
+// NewFormModel returns a FormModel with the default parameters.
+func NewFormModel() *FormModel {
+	return &FormModel{
+		Window: DefaultFormWindow,
+		Decay:  DefaultFormDecay,
+		Weight: DefaultFormWeight,
+	}
+}
+
+// teamForm holds a team's recent scoring and conceding relative to the
+// league average; 1 is average. Confidence grows from 0 to 1 as the window
+// fills up.
+type teamForm struct {
+	attack     float64
+	defence    float64
+	confidence float64
+}
+
+// assess rates every team that has played at least one of matches.
+func (m *FormModel) assess(matches []models.Match) map[int]teamForm {
+	var played []models.Match
+	homeGoals, awayGoals := 0, 0
+	for _, match := range matches {
+		if match.Played {
+			played = append(played, match)
+			homeGoals += match.HomeGoals
+			awayGoals += match.AwayGoals
+		}
+	}
+	forms := make(map[int]teamForm)
+	if len(played) == 0 || m.Window <= 0 {
+		return forms
+	}
+	sort.SliceStable(played, func(i, j int) bool { return played[i].Week < played[j].Week })
+
+	// One goal per side is added so a league of goalless draws still has a
+	// usable average.
+	n := float64(len(played))
+	avgHome := (float64(homeGoals) + 1) / (n + 1)
+	avgAway := (float64(awayGoals) + 1) / (n + 1)
+
+	fullWeight := 0.0
+	for i := 0; i < m.Window; i++ {
+		fullWeight += math.Pow(m.Decay, float64(i))
+	}
+
+	recent := make(map[int][]models.Match)
+	for i := len(played) - 1; i >= 0; i-- {
+		match := played[i]
+		for _, id := range []int{match.Home.ID, match.Away.ID} {
+			if len(recent[id]) < m.Window {
+				recent[id] = append(recent[id], match)
+			}
+		}
+	}
+
+	for id, teamMatches := range recent {
+		// Start from one average match so a single blank does not zero a
+		// side's expected goals.
+		attack, defence, total := 1.0, 1.0, 1.0
+		weight := 1.0
+		for _, match := range teamMatches {
+			scored, conceded := float64(match.HomeGoals)/avgHome, float64(match.AwayGoals)/avgAway
+			if match.Away.ID == id {
+				scored, conceded = float64(match.AwayGoals)/avgAway, float64(match.HomeGoals)/avgHome
+			}
+			attack += weight * scored
+			defence += weight * conceded
+			total += weight
+			weight *= m.Decay
+		}
+		forms[id] = teamForm{
+			attack:     attack / total,
+			defence:    defence / total,
+			confidence: (total - 1) / fullWeight,
+		}
+	}
+	return forms
+}
+
+// Bind returns an engine that plays like base with expected goals adjusted
+// by the form shown in matches. Engines that are not a GoalModel are
+// returned unchanged.
+func (m *FormModel) Bind(base MatchEngine, matches []models.Match) MatchEngine {
+	model, ok := base.(GoalModel)
+	if !ok {
+		return base
+	}
+	return &formEngine{base: model, weight: m.Weight, forms: m.assess(matches)}
+}
+
+// formEngine scales a GoalModel's expected goals by the attacking side's
+// scoring form and the defending side's conceding form.
+type formEngine struct {
+	base   GoalModel
+	weight float64
+	forms  map[int]teamForm
+}
+
+// multiplier returns the factor applied to the goals attacker scores against
+// defender.
+func (e *formEngine) multiplier(attacker, defender models.Team) float64 {
+	factor := 1.0
+	if form, ok := e.forms[attacker.ID]; ok {
+		factor *= math.Pow(form.attack, e.weight*form.confidence)
+	}
+	if form, ok := e.forms[defender.ID]; ok {
+		factor *= math.Pow(form.defence, e.weight*form.confidence)
+	}
+	return factor
+}
+
+// ExpectedGoals returns the mean goals for the home and away sides.
+func (e *formEngine) ExpectedGoals(home, away models.Team) (float64, float64) {
+	homeXG, awayXG := e.base.ExpectedGoals(home, away)
+	return homeXG * e.multiplier(home, away), awayXG * e.multiplier(away, home)
+}
+
+// PlayMatch simulates a match and returns the goals scored by each side.
+func (e *formEngine) PlayMatch(home, away models.Team, rng *rand.Rand) (int, int) {
+	homeXG, awayXG := e.ExpectedGoals(home, away)
+	return poisson(rng, homeXG), poisson(rng, awayXG)
+}
+
+// ScorelineProbability returns the probability of the match ending
+// homeGoals-awayGoals.
+func (e *formEngine) ScorelineProbability(home, away models.Team, homeGoals, awayGoals int) float64 {
+	homeXG, awayXG := e.ExpectedGoals(home, away)
+	return poissonPMF(homeXG, homeGoals) * poissonPMF(awayXG, awayGoals)
+}
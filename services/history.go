@@ -0,0 +1,164 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrNothingToUndo is returned by Undo when every event has been undone.
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Redo when no undone event remains.
+	ErrNothingToRedo = errors.New("nothing to redo")
+)
+
+// EventType names an entry in a league's history.
+type EventType string
+
+const (
+	EventWeekSimulated EventType = "WeekSimulated"
+	EventMatchEdited   EventType = "MatchEdited"
+	EventReset         EventType = "Reset"
+)
+
+// MatchResult is the result an event wrote to a single match, with its
+// timeline when the engine played one.
+type MatchResult struct {
+	MatchID   int
+	HomeGoals int
+	AwayGoals int
+	Played    bool
+	HalfTime  *models.Score       `json:",omitempty"`
+	Events    []models.MatchEvent `json:",omitempty"`
+}
+
+// apply writes the result onto match, replacing any previous timeline.
+func (r MatchResult) apply(match *models.Match) {
+	match.HomeGoals = r.HomeGoals
+	match.AwayGoals = r.AwayGoals
+	match.Played = r.Played
+	match.HalfTime = r.HalfTime
+	match.Events = r.Events
+}
+
+// HistoryEvent records one change to a league. Events carry the results
+// they produced, so replaying them never re-runs the match engine.
+type HistoryEvent struct {
+	Seq  int
+	Type EventType
+	Time time.Time
+	// Week is the week an EventWeekSimulated event played.
+	Week    int
+	Results []MatchResult
+}
+
+// History returns a copy of the league's event log and the number of events
+// currently applied; events at or after the cursor have been undone.
+func (s *SimulatorImpl) History() ([]HistoryEvent, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]HistoryEvent(nil), s.history...), s.cursor
+}
+
+// Undo reverts the most recently applied event.
+func (s *SimulatorImpl) Undo() (HistoryEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cursor == 0 {
+		return HistoryEvent{}, ErrNothingToUndo
+	}
+	s.cursor--
+	s.replayHistory()
+	s.publishStandings()
+	s.persist()
+	return s.history[s.cursor], nil
+}
+
+// Redo re-applies the most recently undone event.
+func (s *SimulatorImpl) Redo() (HistoryEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cursor == len(s.history) {
+		return HistoryEvent{}, ErrNothingToRedo
+	}
+	s.cursor++
+	s.replayHistory()
+	s.publishStandings()
+	s.persist()
+	return s.history[s.cursor-1], nil
+}
+
+// recordEvent appends an event after the cursor, discarding any undone
+// events. Callers must hold mu.
+func (s *SimulatorImpl) recordEvent(event HistoryEvent) {
+	s.history = s.history[:s.cursor]
+	event.Seq = 1
+	if len(s.history) > 0 {
+		event.Seq = s.history[len(s.history)-1].Seq + 1
+	}
+	event.Time = time.Now().UTC()
+	s.history = append(s.history, event)
+	s.cursor = len(s.history)
+	// The playoff was drawn from a table that no longer stands
+	s.playoff = nil
+}
+
+// clearHistory forgets the event log, e.g. after the fixtures are
+// regenerated. Callers must hold mu.
+func (s *SimulatorImpl) clearHistory() {
+	s.history = nil
+	s.cursor = 0
+}
+
+// replayHistory rebuilds matches, the current week and the table from the
+// events before the cursor. Callers must hold mu.
+func (s *SimulatorImpl) replayHistory() {
+	clearResults(s.matches)
+
+	for _, event := range s.history[:s.cursor] {
+		switch event.Type {
+		case EventReset:
+			clearResults(s.matches)
+		case EventWeekSimulated, EventMatchEdited:
+			s.applyResults(event.Results)
+		}
+	}
+
+	s.currentWeek = s.firstUnplayedWeek()
+	s.recalculateStandings()
+	s.playoff = nil
+}
+
+// applyResults writes recorded results onto the matches. Callers must hold mu.
+func (s *SimulatorImpl) applyResults(results []MatchResult) {
+	for _, result := range results {
+		if match := s.findMatch(result.MatchID); match != nil {
+			result.apply(match)
+		}
+	}
+}
+
+// findMatch returns a pointer into s.matches, or nil. Callers must hold mu.
+func (s *SimulatorImpl) findMatch(matchID int) *models.Match {
+	for weekIdx := range s.matches {
+		for matchIdx := range s.matches[weekIdx] {
+			if s.matches[weekIdx][matchIdx].ID == matchID {
+				return &s.matches[weekIdx][matchIdx]
+			}
+		}
+	}
+	return nil
+}
+
+// clearResults marks every match unplayed.
+func clearResults(matches [][]models.Match) {
+	for weekIdx := range matches {
+		for matchIdx := range matches[weekIdx] {
+			MatchResult{}.apply(&matches[weekIdx][matchIdx])
+		}
+	}
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrPlayerNotFound is returned when no player in the league has the requested ID.
+	ErrPlayerNotFound = errors.New("player not found")
+	// ErrInvalidPlayer is returned for a player with a missing name, unknown
+	// position or out of range rating.
+	ErrInvalidPlayer = errors.New("invalid player")
+)
+
+const (
+	// DefaultPlayerRating is given to players created without a rating.
+	DefaultPlayerRating = 50
+	// MaxPlayerRating is the highest rating a player can have.
+	MaxPlayerRating = 100
+)
+
+// scoringWeights and bookingWeights make a player's share of the team's goals
+// and cards proportional to the weight of the position times the rating.
+var (
+	scoringWeights = map[string]float64{
+		models.Goalkeeper: 0.05,
+		models.Defender:   1,
+		models.Midfielder: 3,
+		models.Forward:    6,
+	}
+	bookingWeights = map[string]float64{
+		models.Goalkeeper: 0.5,
+		models.Defender:   3,
+		models.Midfielder: 3,
+		models.Forward:    2,
+	}
+)
+
+// PlayerUpdate lists the fields UpdatePlayer changes. Nil fields keep their
+// current value.
+type PlayerUpdate struct {
+	Name     *string
+	Position *string
+	Rating   *int
+	TeamID   *int
+}
+
+// PlayerStats is a player's record over the season so far: the goals and
+// cards credited to them in the stored timelines.
+type PlayerStats struct {
+	Player      models.Player
+	Team        models.Team
+	Goals       int
+	YellowCards int
+	RedCards    int
+}
+
+// Players returns a team's squad in the order it was signed.
+func (s *SimulatorImpl) Players(teamID int) ([]models.Player, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.teamIndex(teamID) < 0 {
+		return nil, ErrTeamNotFound
+	}
+	players := []models.Player{}
+	for _, player := range s.players {
+		if player.TeamID == teamID {
+			players = append(players, player)
+		}
+	}
+	return players, nil
+}
+
+// Player returns a player by ID.
+func (s *SimulatorImpl) Player(playerID int) (models.Player, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if idx := s.playerIndex(playerID); idx >= 0 {
+		return s.players[idx], nil
+	}
+	return models.Player{}, ErrPlayerNotFound
+}
+
+// AddPlayer adds a player to the squad of player.TeamID. A zero ID is
+// replaced by the league's next player ID, which is never reused once
+// given out, even if that player is removed.
+func (s *SimulatorImpl) AddPlayer(player models.Player) (models.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.teamIndex(player.TeamID) < 0 {
+		return models.Player{}, ErrTeamNotFound
+	}
+	if err := validatePlayer(&player); err != nil {
+		return models.Player{}, err
+	}
+	if player.ID == 0 {
+		player.ID = s.nextPlayerID
+	}
+	if s.playerIndex(player.ID) >= 0 {
+		return models.Player{}, fmt.Errorf("%w: duplicate player ID %d", ErrInvalidPlayer, player.ID)
+	}
+
+	s.players = append(s.players, player)
+	s.nextPlayerID = max(s.nextPlayerID, player.ID+1)
+	s.persist()
+	return player, nil
+}
+
+// UpdatePlayer changes a player's details. The update is merged into the
+// player under the same lock that stores it, so concurrent updates, transfers
+// and removals do not undo each other. Changing TeamID transfers the player;
+// goals already scored stay in the old team's matches.
+func (s *SimulatorImpl) UpdatePlayer(playerID int, update PlayerUpdate) (models.Player, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.playerIndex(playerID)
+	if idx < 0 {
+		return models.Player{}, ErrPlayerNotFound
+	}
+	player := s.players[idx]
+	if update.Name != nil {
+		player.Name = *update.Name
+	}
+	if update.Position != nil {
+		player.Position = *update.Position
+	}
+	if update.Rating != nil {
+		player.Rating = *update.Rating
+	}
+	if update.TeamID != nil {
+		player.TeamID = *update.TeamID
+	}
+	if s.teamIndex(player.TeamID) < 0 {
+		return models.Player{}, ErrTeamNotFound
+	}
+	if err := validatePlayer(&player); err != nil {
+		return models.Player{}, err
+	}
+
+	s.players[idx] = player
+	s.persist()
+	return player, nil
+}
+
+// RemovePlayer removes a player from the squad. Events the player took part
+// in keep the ID but no longer count towards any statistics.
+func (s *SimulatorImpl) RemovePlayer(playerID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.playerIndex(playerID)
+	if idx < 0 {
+		return ErrPlayerNotFound
+	}
+	s.players = append(s.players[:idx:idx], s.players[idx+1:]...)
+	s.persist()
+	return nil
+}
+
+// PlayerStats returns the season record of every player in the league, top
+// scorers first.
+func (s *SimulatorImpl) PlayerStats() []PlayerStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make(map[int]*PlayerStats, len(s.players))
+	all := make([]*PlayerStats, 0, len(s.players))
+	for _, player := range s.players {
+		st := &PlayerStats{Player: player}
+		if idx := s.teamIndex(player.TeamID); idx >= 0 {
+			st.Team = s.teams[idx]
+		}
+		stats[player.ID] = st
+		all = append(all, st)
+	}
+
+	for _, weekMatches := range s.matches {
+		for _, match := range weekMatches {
+			for _, event := range match.Events {
+				st, ok := stats[event.PlayerID]
+				if !ok {
+					continue
+				}
+				switch event.Type {
+				case models.EventGoal:
+					st.Goals++
+				case models.EventYellowCard:
+					st.YellowCards++
+				case models.EventRedCard:
+					st.RedCards++
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].Goals != all[j].Goals {
+			return all[i].Goals > all[j].Goals
+		}
+		return all[i].Player.ID < all[j].Player.ID
+	})
+	result := make([]PlayerStats, len(all))
+	for i, st := range all {
+		result[i] = *st
+	}
+	return result
+}
+
+// assignPlayers credits the goals and cards of a timeline to players of the
+// side involved, weighted by position and rating. Players sent off take no
+// further part. Substitutions are never assigned: without line-ups there is
+// no telling who came on or off. Events of teams without a squad are left
+// unassigned too.
+// Callers must hold mu.
+func (s *SimulatorImpl) assignPlayers(events []models.MatchEvent, rng *rand.Rand) {
+	if len(s.players) == 0 {
+		return
+	}
+	sentOff := make(map[int]bool)
+	for i := range events {
+		event := &events[i]
+		var weights map[string]float64
+		switch event.Type {
+		case models.EventGoal:
+			weights = scoringWeights
+		case models.EventYellowCard, models.EventRedCard:
+			weights = bookingWeights
+		default:
+			continue
+		}
+		event.PlayerID = s.pickPlayer(event.TeamID, weights, sentOff, rng)
+		if event.Type == models.EventRedCard && event.PlayerID != 0 {
+			sentOff[event.PlayerID] = true
+		}
+	}
+}
+
+// pickPlayer draws a player of the team with probability proportional to
+// weights[position] * rating, or returns 0 when there is none. Callers must
+// hold mu.
+func (s *SimulatorImpl) pickPlayer(teamID int, weights map[string]float64, excluded map[int]bool, rng *rand.Rand) int {
+	total := 0.0
+	for _, player := range s.players {
+		if player.TeamID == teamID && !excluded[player.ID] {
+			total += weights[player.Position] * float64(player.Rating)
+		}
+	}
+	if total <= 0 {
+		return 0
+	}
+
+	target := rng.Float64() * total
+	last := 0
+	for _, player := range s.players {
+		if player.TeamID != teamID || excluded[player.ID] {
+			continue
+		}
+		target -= weights[player.Position] * float64(player.Rating)
+		last = player.ID
+		if target < 0 {
+			break
+		}
+	}
+	return last
+}
+
+// playerIndex returns the position of a player in s.players, or -1. Callers
+// must hold mu.
+func (s *SimulatorImpl) playerIndex(playerID int) int {
+	for i, player := range s.players {
+		if player.ID == playerID {
+			return i
+		}
+	}
+	return -1
+}
+
+// validatePlayer trims the name, normalises the position and fills in a
+// default rating.
+func validatePlayer(player *models.Player) error {
+	player.Name = strings.TrimSpace(player.Name)
+	if player.Name == "" {
+		return fmt.Errorf("%w: player name is required", ErrInvalidPlayer)
+	}
+	player.Position = strings.ToUpper(strings.TrimSpace(player.Position))
+	if _, ok := scoringWeights[player.Position]; !ok {
+		return fmt.Errorf("%w: position must be GK, DF, MF or FW", ErrInvalidPlayer)
+	}
+	if player.Rating == 0 {
+		player.Rating = DefaultPlayerRating
+	}
+	if player.ID < 0 || player.Rating < 0 || player.Rating > MaxPlayerRating {
+		return fmt.Errorf("%w: %s has a negative ID or a rating outside 1-%d", ErrInvalidPlayer, player.Name, MaxPlayerRating)
+	}
+	return nil
+}
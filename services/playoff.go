@@ -0,0 +1,238 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrPlayoffNotFound is returned when the league has no playoff configured.
+	ErrPlayoffNotFound = errors.New("playoff not found")
+	// ErrInvalidPlayoff is returned for places outside the table, an unknown
+	// outcome or number of legs.
+	ErrInvalidPlayoff = errors.New("invalid playoff")
+	// ErrPlayoffFinished is returned by SimulatePlayoffRound once the final
+	// is decided.
+	ErrPlayoffFinished = errors.New("playoff finished: no rounds left to simulate")
+)
+
+// Playoff outcomes: what the winner of the final earns. A promotion is only
+// recorded; a league is not part of a pyramid, so the winner stays in it.
+const (
+	PlayoffChampion  = "champion"
+	PlayoffPromotion = "promotion"
+)
+
+// PlayoffConfig attaches an end-of-season playoff to a league. The teams
+// ranked From to To in the final table are seeded in table order, so the
+// top seeds get any byes.
+type PlayoffConfig struct {
+	Name string
+	From int
+	To   int
+	// Legs is 1 for single matches or 2 for home and away ties before the
+	// final, which is always a single match.
+	Legs    int
+	Outcome string
+}
+
+// Playoff is a league's playoff: its configuration and, once the regular
+// season is over and the first round has been played, the bracket. Winner
+// is the zero team until the final is decided.
+type Playoff struct {
+	Config PlayoffConfig
+	Rounds []CupRound
+	Winner models.Team
+}
+
+// Decided reports whether the playoff final has been played.
+func (p Playoff) Decided() bool {
+	return p.Winner.ID != 0
+}
+
+// PlayoffState is a league's playoff as saved: the configuration and the
+// bracket, if it has been drawn.
+type PlayoffState struct {
+	Config  PlayoffConfig
+	Bracket *CupState
+}
+
+// SetPlayoff configures the league's playoff, replacing any existing one
+// along with its bracket.
+func (s *SimulatorImpl) SetPlayoff(config PlayoffConfig) (PlayoffConfig, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if config.Name == "" {
+		config.Name = "Playoffs"
+	}
+	if config.Legs == 0 {
+		config.Legs = 1
+	}
+	if config.Outcome == "" {
+		config.Outcome = PlayoffChampion
+	}
+	if err := validatePlayoff(config, len(s.teams)); err != nil {
+		return PlayoffConfig{}, err
+	}
+
+	s.playoffConfig = &config
+	s.playoff = nil
+	s.persist()
+	return config, nil
+}
+
+func validatePlayoff(config PlayoffConfig, teams int) error {
+	if config.From < 1 || config.To <= config.From {
+		return fmt.Errorf("%w: from must be 1 or more and to below it", ErrInvalidPlayoff)
+	}
+	if config.To > teams {
+		return fmt.Errorf("%w: place %d is below the bottom of a %d-team table", ErrInvalidPlayoff, config.To, teams)
+	}
+	if config.Legs != 1 && config.Legs != 2 {
+		return fmt.Errorf("%w: ties must have 1 or 2 legs", ErrInvalidPlayoff)
+	}
+	if config.Outcome != PlayoffChampion && config.Outcome != PlayoffPromotion {
+		return fmt.Errorf("%w: outcome must be %q or %q", ErrInvalidPlayoff, PlayoffChampion, PlayoffPromotion)
+	}
+	return nil
+}
+
+// RemovePlayoff detaches the league's playoff.
+func (s *SimulatorImpl) RemovePlayoff() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.playoffConfig == nil {
+		return ErrPlayoffNotFound
+	}
+	s.playoffConfig = nil
+	s.playoff = nil
+	s.persist()
+	return nil
+}
+
+// Playoff returns the league's playoff for the current season.
+func (s *SimulatorImpl) Playoff() (Playoff, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.playoffConfig == nil {
+		return Playoff{}, ErrPlayoffNotFound
+	}
+	return s.currentPlayoff(), nil
+}
+
+// currentPlayoff copies the playoff out of the league. Callers must hold mu
+// and have checked that a playoff is configured.
+func (s *SimulatorImpl) currentPlayoff() Playoff {
+	playoff := Playoff{Config: *s.playoffConfig}
+	if s.playoff != nil {
+		playoff.Rounds = s.playoff.Bracket()
+		playoff.Winner, _ = s.playoff.Champion()
+	}
+	return playoff
+}
+
+// SimulatePlayoffRound plays the next round of the playoff, drawing the
+// bracket from the final table first if needed.
+func (s *SimulatorImpl) SimulatePlayoffRound() (CupRound, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.preparePlayoff(); err != nil {
+		return CupRound{}, err
+	}
+	round, err := s.playoff.SimulateRound()
+	if errors.Is(err, ErrCupFinished) {
+		return CupRound{}, ErrPlayoffFinished
+	}
+	if err != nil {
+		return CupRound{}, err
+	}
+	s.persist()
+	return round, nil
+}
+
+// SimulatePlayoff plays the rest of the playoff.
+func (s *SimulatorImpl) SimulatePlayoff() (Playoff, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.preparePlayoff(); err != nil {
+		return Playoff{}, err
+	}
+	var err error
+	for err == nil {
+		_, err = s.playoff.SimulateRound()
+	}
+	s.persist()
+	if !errors.Is(err, ErrCupFinished) {
+		return Playoff{}, err
+	}
+	return s.currentPlayoff(), nil
+}
+
+// preparePlayoff checks that the playoff can be played and draws the
+// bracket if it has not been drawn yet. Callers must hold mu.
+func (s *SimulatorImpl) preparePlayoff() error {
+	if s.playoffConfig == nil {
+		return ErrPlayoffNotFound
+	}
+	if s.firstUnplayedWeek() < len(s.matches) {
+		return fmt.Errorf("%w: the playoff starts once every match of season %d is played", ErrSeasonInProgress, s.season)
+	}
+	if s.playoff != nil {
+		return nil
+	}
+
+	config := *s.playoffConfig
+	table := s.sortedStandings()
+	if err := validatePlayoff(config, len(table)); err != nil {
+		return err
+	}
+	teams := make([]models.Team, 0, config.To-config.From+1)
+	for _, standing := range table[config.From-1 : config.To] {
+		teams = append(teams, standing.Team)
+	}
+	seed := rand.New(rand.NewSource(s.seasonSeed())).Int63()
+	cup, err := newCup(teams, CupOptions{Legs: config.Legs, Seed: &seed, Engine: s.engine})
+	if err != nil {
+		return err
+	}
+	cup.name = config.Name
+	cup.rounds = cup.drawBracket()
+	s.playoff = cup
+	return nil
+}
+
+// playoffState copies the playoff for saving, or returns nil when none is
+// configured. Callers must hold mu.
+func (s *SimulatorImpl) playoffState() *PlayoffState {
+	if s.playoffConfig == nil {
+		return nil
+	}
+	state := &PlayoffState{Config: *s.playoffConfig}
+	if s.playoff != nil {
+		s.playoff.mu.RLock()
+		bracket := s.playoff.state()
+		s.playoff.mu.RUnlock()
+		state.Bracket = &bracket
+	}
+	return state
+}
+
+// restorePlayoff reattaches a saved playoff. Callers must hold mu or own s.
+func (s *SimulatorImpl) restorePlayoff(state *PlayoffState) {
+	if state == nil {
+		return
+	}
+	config := state.Config
+	s.playoffConfig = &config
+	if state.Bracket != nil {
+		s.playoff = restoreCup(*state.Bracket)
+	}
+}
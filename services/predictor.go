@@ -1,76 +1,366 @@
 package services
 
-import "league-simulator/models"
+import (
+	"math"
+	"math/rand"
+	"sort"
 
+	"league-simulator/models"
+)
+
+// Predictor forecasts the rest of a season. Every method takes the full
+// fixture list: played matches give each side's recent form and unplayed
+// ones are predicted.
 type Predictor interface {
-	PredictFinalStandings(currentMatches []models.Match, standings map[int]*models.Standing) map[int]*models.Standing
+	PredictFinalStandings(currentMatches []models.Match, standings map[int]*models.Standing, opts ForecastOptions) []models.Standing
+	ForecastPositions(currentMatches []models.Match, standings map[int]*models.Standing, opts ForecastOptions) Forecast
+	PredictMatches(matches []models.Match, opts ForecastOptions) []MatchForecast
+}
+
+type predictorImpl struct {
+	form *FormModel
 }
 
-type predictorImpl struct{}
+// PredictorOption configures a predictor at construction time.
+type PredictorOption func(*predictorImpl)
 
-func NewPredictor() Predictor {
-	return &predictorImpl{}
+// WithFormModel sets how recent results adjust the match engine. A nil model
+// predicts from strengths or ratings alone.
+func WithFormModel(form *FormModel) PredictorOption {
+	return func(p *predictorImpl) {
+		p.form = form
+	}
 }
 
-func (p *predictorImpl) PredictFinalStandings(currentMatches []models.Match, standings map[int]*models.Standing) map[int]*models.Standing {
-	// Create a copy of current standings for prediction
-	predictedStandings := make(map[int]*models.Standing)
+// NewPredictor returns a Predictor that weights recent form with the default
+// FormModel.
+func NewPredictor(opts ...PredictorOption) Predictor {
+	p := &predictorImpl{form: NewFormModel()}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// PredictFinalStandings plays every unplayed match as its most likely
+// outcome, with the most likely score for that outcome, and returns the
+// resulting table ranked by opts.Tiebreakers.
+func (p *predictorImpl) PredictFinalStandings(currentMatches []models.Match, standings map[int]*models.Standing, opts ForecastOptions) []models.Standing {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultForecastIterations
+	}
+	engine := p.engine(opts, currentMatches)
+	rules := opts.Tiebreakers
+	if rules == nil {
+		rules = DefaultTiebreakers
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	predicted := make(map[int]*models.Standing, len(standings))
 	for id, standing := range standings {
-		predictedStandings[id] = &models.Standing{
-			Team:         standing.Team,
-			Played:       standing.Played,
-			Won:          standing.Won,
-			Drawn:        standing.Drawn,
-			Lost:         standing.Lost,
-			GoalsFor:     standing.GoalsFor,
-			GoalsAgainst: standing.GoalsAgainst,
-			GoalDiff:     standing.GoalDiff,
-			Points:       standing.Points,
-		}
+		copied := *standing
+		predicted[id] = &copied
 	}
 
-	// Simulate remaining matches based on current form
+	ctx := rankContext{seed: opts.Seed}
 	for _, match := range currentMatches {
 		if !match.Played {
-			homeStanding := predictedStandings[match.Home.ID]
-			awayStanding := predictedStandings[match.Away.ID]
+			forecast := predictMatch(engine, match, rng, opts.Iterations)
+			best := forecast.mostLikely()
+			match.HomeGoals, match.AwayGoals = best.HomeGoals, best.AwayGoals
+			match.Played = true
+			updateStandings(predicted, match)
+		}
+		ctx.matches = append(ctx.matches, match)
+	}
 
-			// Calculate expected points based on current form
-			homePointsPerGame := 0.0
-			awayPointsPerGame := 0.0
+	table := make([]models.Standing, 0, len(predicted))
+	for _, standing := range predicted {
+		table = append(table, *standing)
+	}
+	rankStandings(table, rules, ctx)
+	return table
+}
 
-			if homeStanding.Played > 0 {
-				homePointsPerGame = float64(homeStanding.Points) / float64(homeStanding.Played)
-			}
-			if awayStanding.Played > 0 {
-				awayPointsPerGame = float64(awayStanding.Points) / float64(awayStanding.Played)
+// DefaultForecastIterations is the number of seasons simulated when
+// ForecastOptions.Iterations is not set.
+const DefaultForecastIterations = 10000
+
+// ForecastOptions controls a Monte Carlo forecast of the final table.
+type ForecastOptions struct {
+	Iterations int
+	Seed       int64
+	// Engine plays the remaining fixtures; a PoissonEngine is used when nil.
+	Engine MatchEngine
+	// Tiebreakers ranks each simulated table; DefaultTiebreakers when nil.
+	Tiebreakers []Tiebreaker
+	// Ratings are bound to a RatedEngine before it plays any match.
+	Ratings map[int]float64
+}
+
+// TeamForecast is one team's simulated finishing distribution.
+type TeamForecast struct {
+	Team models.Team
+	// PositionProbabilities[i] is the probability of finishing in position i+1.
+	PositionProbabilities []float64
+	ChampionProbability   float64
+	// StandardError is the Monte Carlo standard error of ChampionProbability.
+	StandardError  float64
+	ExpectedPoints float64
+}
+
+// Forecast is the outcome of a Monte Carlo run over the remaining fixtures.
+type Forecast struct {
+	Iterations int
+	Seed       int64
+	Teams      []TeamForecast
+	// MaxStandardError is the largest standard error over every team and position.
+	MaxStandardError float64
+}
+
+// engine returns the engine a forecast plays with: opts.Engine, or a
+// PoissonEngine when nil, bound to opts.Ratings if it is a RatedEngine and to
+// the form shown in matches.
+func (p *predictorImpl) engine(opts ForecastOptions, matches []models.Match) MatchEngine {
+	engine := opts.Engine
+	if engine == nil {
+		engine = NewPoissonEngine()
+	}
+	if rated, ok := engine.(RatedEngine); ok && opts.Ratings != nil {
+		engine = rated.WithRatings(opts.Ratings)
+	}
+	if p.form != nil {
+		engine = p.form.Bind(engine, matches)
+	}
+	return engine
+}
+
+// ForecastPositions simulates the unplayed matches opts.Iterations times and
+// counts how often each team finishes in each position.
+func (p *predictorImpl) ForecastPositions(currentMatches []models.Match, standings map[int]*models.Standing, opts ForecastOptions) Forecast {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultForecastIterations
+	}
+	engine := p.engine(opts, currentMatches)
+	rules := opts.Tiebreakers
+	if rules == nil {
+		rules = DefaultTiebreakers
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	var played, remaining []models.Match
+	for _, match := range currentMatches {
+		if match.Played {
+			played = append(played, match)
+		} else {
+			remaining = append(remaining, match)
+		}
+	}
+	withResults := needsMatches(rules)
+	var ctx rankContext
+
+	numTeams := len(standings)
+	positionCounts := make(map[int][]int, numTeams)
+	totalPoints := make(map[int]int, numTeams)
+	for id := range standings {
+		positionCounts[id] = make([]int, numTeams)
+	}
+
+	simulated := make(map[int]*models.Standing, numTeams)
+	table := make([]models.Standing, 0, numTeams)
+	for i := 0; i < opts.Iterations; i++ {
+		for id, standing := range standings {
+			copied := *standing
+			simulated[id] = &copied
+		}
+		// Fresh lots every season so fully level teams are not always drawn the same way
+		ctx.seed = rng.Int63()
+		if withResults {
+			ctx.matches = append(ctx.matches[:0], played...)
+		}
+		for _, match := range remaining {
+			match.HomeGoals, match.AwayGoals = engine.PlayMatch(match.Home, match.Away, rng)
+			match.Played = true
+			updateStandings(simulated, match)
+			if withResults {
+				ctx.matches = append(ctx.matches, match)
 			}
+		}
 
-			// Determine likely result based on form difference (no home advantage)
-			formDifference := homePointsPerGame - awayPointsPerGame
-
-			if formDifference > 0.5 {
-				// Home team likely to win
-				homeStanding.Won++
-				homeStanding.Points += 3
-				awayStanding.Lost++
-			} else if formDifference < -0.5 {
-				// Away team likely to win
-				awayStanding.Won++
-				awayStanding.Points += 3
-				homeStanding.Lost++
-			} else {
-				// Draw likely
-				homeStanding.Drawn++
-				homeStanding.Points += 1
-				awayStanding.Drawn++
-				awayStanding.Points += 1
+		table = table[:0]
+		for _, standing := range simulated {
+			table = append(table, *standing)
+		}
+		rankStandings(table, rules, ctx)
+		for pos, standing := range table {
+			positionCounts[standing.Team.ID][pos]++
+			totalPoints[standing.Team.ID] += standing.Points
+		}
+	}
+
+	forecast := Forecast{Iterations: opts.Iterations, Seed: opts.Seed}
+	n := float64(opts.Iterations)
+	for id, standing := range standings {
+		tf := TeamForecast{
+			Team:                  standing.Team,
+			PositionProbabilities: make([]float64, numTeams),
+			ExpectedPoints:        float64(totalPoints[id]) / n,
+		}
+		for pos, count := range positionCounts[id] {
+			prob := float64(count) / n
+			tf.PositionProbabilities[pos] = prob
+			forecast.MaxStandardError = math.Max(forecast.MaxStandardError, standardError(prob, n))
+		}
+		tf.ChampionProbability = tf.PositionProbabilities[0]
+		tf.StandardError = standardError(tf.ChampionProbability, n)
+		forecast.Teams = append(forecast.Teams, tf)
+	}
+
+	sort.Slice(forecast.Teams, func(i, j int) bool {
+		if forecast.Teams[i].ChampionProbability != forecast.Teams[j].ChampionProbability {
+			return forecast.Teams[i].ChampionProbability > forecast.Teams[j].ChampionProbability
+		}
+		if forecast.Teams[i].ExpectedPoints != forecast.Teams[j].ExpectedPoints {
+			return forecast.Teams[i].ExpectedPoints > forecast.Teams[j].ExpectedPoints
+		}
+		return forecast.Teams[i].Team.ID < forecast.Teams[j].Team.ID
+	})
+
+	return forecast
+}
+
+// standardError is the standard error of a proportion p estimated from n samples.
+func standardError(p, n float64) float64 {
+	return math.Sqrt(p * (1 - p) / n)
+}
+
+const (
+	// maxScorelineGoals bounds the per-side goals enumerated for exact scoreline models.
+	maxScorelineGoals = 10
+	// topScorelines is the number of most likely scorelines reported per match.
+	topScorelines = 5
+)
+
+// Scoreline is a final score together with its probability.
+type Scoreline struct {
+	HomeGoals   int
+	AwayGoals   int
+	Probability float64
+}
+
+// MatchForecast holds the outcome probabilities of a single fixture.
+type MatchForecast struct {
+	Match   models.Match
+	HomeWin float64
+	Draw    float64
+	AwayWin float64
+	// Scorelines lists the most likely final scores, most likely first.
+	Scorelines []Scoreline
+}
+
+// PredictMatches returns win/draw/loss probabilities and the most likely
+// scorelines for every unplayed match. Engines implementing ScorelineModel
+// are evaluated exactly; any other engine is sampled opts.Iterations times.
+func (p *predictorImpl) PredictMatches(matches []models.Match, opts ForecastOptions) []MatchForecast {
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultForecastIterations
+	}
+	engine := p.engine(opts, matches)
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	var forecasts []MatchForecast
+	for _, match := range matches {
+		if match.Played {
+			continue
+		}
+		forecast := predictMatch(engine, match, rng, opts.Iterations)
+		if len(forecast.Scorelines) > topScorelines {
+			forecast.Scorelines = forecast.Scorelines[:topScorelines]
+		}
+		forecasts = append(forecasts, forecast)
+	}
+
+	return forecasts
+}
+
+// predictMatch returns the outcome probabilities of match and every
+// scoreline considered, most likely first.
+func predictMatch(engine MatchEngine, match models.Match, rng *rand.Rand, iterations int) MatchForecast {
+	var scorelines []Scoreline
+	if model, ok := engine.(ScorelineModel); ok {
+		for h := 0; h <= maxScorelineGoals; h++ {
+			for a := 0; a <= maxScorelineGoals; a++ {
+				scorelines = append(scorelines, Scoreline{
+					HomeGoals:   h,
+					AwayGoals:   a,
+					Probability: model.ScorelineProbability(match.Home, match.Away, h, a),
+				})
 			}
+		}
+	} else {
+		counts := make(map[[2]int]int)
+		for i := 0; i < iterations; i++ {
+			h, a := engine.PlayMatch(match.Home, match.Away, rng)
+			counts[[2]int{h, a}]++
+		}
+		for score, count := range counts {
+			scorelines = append(scorelines, Scoreline{
+				HomeGoals:   score[0],
+				AwayGoals:   score[1],
+				Probability: float64(count) / float64(iterations),
+			})
+		}
+	}
 
-			homeStanding.Played++
-			awayStanding.Played++
+	forecast := MatchForecast{Match: match}
+	total := 0.0
+	for _, sl := range scorelines {
+		total += sl.Probability
+		switch {
+		case sl.HomeGoals > sl.AwayGoals:
+			forecast.HomeWin += sl.Probability
+		case sl.HomeGoals < sl.AwayGoals:
+			forecast.AwayWin += sl.Probability
+		default:
+			forecast.Draw += sl.Probability
 		}
 	}
+	// Renormalise so the truncated grid still sums to one
+	if total > 0 {
+		forecast.HomeWin /= total
+		forecast.Draw /= total
+		forecast.AwayWin /= total
+	}
+
+	sort.Slice(scorelines, func(i, j int) bool {
+		if scorelines[i].Probability != scorelines[j].Probability {
+			return scorelines[i].Probability > scorelines[j].Probability
+		}
+		if scorelines[i].HomeGoals != scorelines[j].HomeGoals {
+			return scorelines[i].HomeGoals < scorelines[j].HomeGoals
+		}
+		return scorelines[i].AwayGoals < scorelines[j].AwayGoals
+	})
+	forecast.Scorelines = scorelines
+	return forecast
+}
 
-	return predictedStandings
+// mostLikely returns the most likely scoreline of the most likely outcome,
+// so a match a side is favoured to win is never predicted as a draw just
+// because 1-1 is the single likeliest score.
+func (f MatchForecast) mostLikely() Scoreline {
+	outcome := 0
+	switch {
+	case f.HomeWin >= f.Draw && f.HomeWin >= f.AwayWin:
+		outcome = 1
+	case f.AwayWin > f.Draw:
+		outcome = -1
+	}
+	for _, sl := range f.Scorelines {
+		diff := sl.HomeGoals - sl.AwayGoals
+		if (outcome == 1 && diff > 0) || (outcome == -1 && diff < 0) || (outcome == 0 && diff == 0) {
+			return sl
+		}
+	}
+	return Scoreline{}
 }
@@ -0,0 +1,532 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrPyramidNotFound is returned when no pyramid has the requested ID.
+	ErrPyramidNotFound = errors.New("pyramid not found")
+	// ErrInvalidPyramid is returned for divisions whose promotion, playoff
+	// and relegation places do not line up between tiers.
+	ErrInvalidPyramid = errors.New("invalid pyramid")
+)
+
+// Table zones reported by DivisionTable.Zone.
+const (
+	ZonePromotion  = "promotion"
+	ZonePlayoff    = "playoff"
+	ZoneRelegation = "relegation"
+)
+
+// DivisionConfig describes one tier of a pyramid. Divisions are listed top
+// tier first. Promotion places go up automatically, the Playoff places
+// below them play off for one more promotion, and Relegation places go
+// down. Each division must relegate as many teams as the one below
+// promotes, so every division keeps its size.
+type DivisionConfig struct {
+	Name       string
+	Teams      []models.Team
+	Promotion  int
+	Playoff    int
+	Relegation int
+}
+
+// PyramidOptions configures a pyramid. Every division plays with the same
+// engine and tiebreakers.
+type PyramidOptions struct {
+	// Seed defaults to the current time.
+	Seed *int64
+	// Engine defaults to a PoissonEngine.
+	Engine MatchEngine
+	// Tiebreakers default to DefaultTiebreakers.
+	Tiebreakers []Tiebreaker
+}
+
+// DivisionTable is a division's table and fixtures in a season.
+type DivisionTable struct {
+	Name        string
+	Promotion   int
+	Playoff     int
+	Relegation  int
+	Table       []models.Standing
+	Matches     [][]models.Match
+	CurrentWeek int
+}
+
+// Zone returns the zone of the 1-based table position, or "" for mid-table.
+func (d DivisionTable) Zone(position int) string {
+	switch {
+	case position <= d.Promotion:
+		return ZonePromotion
+	case position <= d.Promotion+d.Playoff:
+		return ZonePlayoff
+	case position > len(d.Table)-d.Relegation:
+		return ZoneRelegation
+	}
+	return ""
+}
+
+// Movement is a team changing division at the end of a season.
+type Movement struct {
+	Team models.Team
+	From string
+	To   string
+	// Playoff is set for the team promoted through the playoffs.
+	Playoff bool
+}
+
+// PyramidPlayoff is the promotion playoff of one division.
+type PyramidPlayoff struct {
+	Division string
+	Rounds   []CupRound
+	Winner   models.Team
+}
+
+// PyramidSeason is a finished season: the final tables, the playoffs and
+// the movements they led to.
+type PyramidSeason struct {
+	Number    int
+	Tables    []DivisionTable
+	Playoffs  []PyramidPlayoff
+	Movements []Movement
+}
+
+// PyramidDivisionState is a division as saved in a PyramidState.
+type PyramidDivisionState struct {
+	Name       string
+	Promotion  int
+	Playoff    int
+	Relegation int
+	League     LeagueState
+}
+
+// PyramidState is everything needed to restore a Pyramid.
+type PyramidState struct {
+	ID          int
+	Name        string
+	Seed        int64
+	Engine      string
+	Tiebreakers []string
+	Season      int
+	Divisions   []PyramidDivisionState
+	History     []PyramidSeason
+}
+
+// division is one tier, whose current season is played by its own league
+// simulator.
+type division struct {
+	name       string
+	promotion  int
+	playoff    int
+	relegation int
+	league     *SimulatorImpl
+}
+
+// Pyramid is a stack of divisions with promotion and relegation between
+// them. When the last match of a season is played the playoffs are settled
+// and the movements recorded; the next simulation starts a new season with
+// the teams moved and fresh fixtures. It is safe for concurrent use.
+type Pyramid struct {
+	mu          sync.RWMutex
+	id          int
+	name        string
+	seed        int64
+	engine      MatchEngine
+	tiebreakers []Tiebreaker
+	season      int
+	divisions   []division
+	history     []PyramidSeason
+	repo        Repository
+}
+
+// NewPyramid builds a pyramid from divisions, top tier first, and starts its
+// first season. Team IDs are unique across the whole pyramid.
+func NewPyramid(divisions []DivisionConfig, opts PyramidOptions) (*Pyramid, error) {
+	if len(divisions) < 2 {
+		return nil, fmt.Errorf("%w: at least 2 divisions are required", ErrInvalidPyramid)
+	}
+	var all []models.Team
+	for _, config := range divisions {
+		all = append(all, config.Teams...)
+	}
+	all, err := normaliseTeams(all)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateDivisions(divisions); err != nil {
+		return nil, err
+	}
+
+	if opts.Engine == nil {
+		opts.Engine = NewPoissonEngine()
+	}
+	if opts.Tiebreakers == nil {
+		opts.Tiebreakers = DefaultTiebreakers
+	}
+	seed := time.Now().UnixNano()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+
+	p := &Pyramid{
+		seed:        seed,
+		engine:      opts.Engine,
+		tiebreakers: opts.Tiebreakers,
+		season:      1,
+	}
+	for d, config := range divisions {
+		teams := all[:len(config.Teams)]
+		all = all[len(config.Teams):]
+		name := config.Name
+		if name == "" {
+			name = fmt.Sprintf("Division %d", d+1)
+		}
+		p.divisions = append(p.divisions, division{
+			name:       name,
+			promotion:  config.Promotion,
+			playoff:    config.Playoff,
+			relegation: config.Relegation,
+		})
+		p.divisions[d].league = p.newLeague(d, teams)
+	}
+	return p, nil
+}
+
+// validateDivisions checks that every division keeps its size from one
+// season to the next.
+func validateDivisions(divisions []DivisionConfig) error {
+	for d, config := range divisions {
+		name := config.Name
+		if name == "" {
+			name = fmt.Sprintf("Division %d", d+1)
+		}
+		if len(config.Teams) < 2 {
+			return fmt.Errorf("%w: %s needs at least 2 teams", ErrInvalidPyramid, name)
+		}
+		if config.Promotion < 0 || config.Playoff < 0 || config.Relegation < 0 {
+			return fmt.Errorf("%w: %s has negative places", ErrInvalidPyramid, name)
+		}
+		if config.Playoff == 1 {
+			return fmt.Errorf("%w: %s needs at least 2 playoff places or none", ErrInvalidPyramid, name)
+		}
+		if config.Promotion+config.Playoff+config.Relegation > len(config.Teams) {
+			return fmt.Errorf("%w: %s has more places than teams", ErrInvalidPyramid, name)
+		}
+		if d == 0 && config.Promotion+config.Playoff > 0 {
+			return fmt.Errorf("%w: the top division cannot promote", ErrInvalidPyramid)
+		}
+		if d == len(divisions)-1 && config.Relegation > 0 {
+			return fmt.Errorf("%w: the bottom division cannot relegate", ErrInvalidPyramid)
+		}
+		if d > 0 {
+			up := config.Promotion + min(config.Playoff, 1)
+			if divisions[d-1].Relegation != up {
+				return fmt.Errorf("%w: %s promotes %d teams but the division above relegates %d",
+					ErrInvalidPyramid, name, up, divisions[d-1].Relegation)
+			}
+		}
+	}
+	return nil
+}
+
+// restorePyramid rebuilds a pyramid from saved state.
+func restorePyramid(state PyramidState) *Pyramid {
+	p := &Pyramid{
+		id:          state.ID,
+		name:        state.Name,
+		seed:        state.Seed,
+		engine:      NewPoissonEngine(),
+		tiebreakers: DefaultTiebreakers,
+		season:      state.Season,
+		history:     state.History,
+	}
+	if engine, err := ParseEngine(state.Engine); err == nil {
+		p.engine = engine
+	}
+	if rules, err := ParseTiebreakers(state.Tiebreakers); err == nil && len(rules) > 0 {
+		p.tiebreakers = rules
+	}
+	for _, d := range state.Divisions {
+		p.divisions = append(p.divisions, division{
+			name:       d.Name,
+			promotion:  d.Promotion,
+			playoff:    d.Playoff,
+			relegation: d.Relegation,
+			league:     restoreSimulator(d.League),
+		})
+	}
+	return p
+}
+
+// seasonSeed is the seed division d plays the current season with.
+// Callers must hold mu.
+func (p *Pyramid) seasonSeed(d int) int64 {
+	return p.seed + int64((p.season-1)*len(p.divisions)+d) + 1
+}
+
+// newLeague starts division d's season for teams. Callers must hold mu.
+func (p *Pyramid) newLeague(d int, teams []models.Team) *SimulatorImpl {
+	return newSimulator(teams,
+		WithName(fmt.Sprintf("%s %d", p.divisions[d].name, p.season)),
+		WithSeed(p.seasonSeed(d)),
+		WithMatchEngine(p.engine),
+		WithTiebreakers(p.tiebreakers),
+	)
+}
+
+// SimulateWeek plays the next week in every division. After the last week
+// of a season it settles the season; a call after that starts the next
+// season and plays its first week. It fails if a playoff cannot be played,
+// leaving the season unsettled.
+func (p *Pyramid) SimulateWeek() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.simulateWeek()
+	p.persist()
+	return err
+}
+
+// SimulateSeason plays the rest of the current season, or the whole of the
+// next one if the current season is over, and settles it.
+func (p *Pyramid) SimulateSeason() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	err := p.simulateWeek()
+	for err == nil && !p.seasonOver() {
+		err = p.simulateWeek()
+	}
+	p.persist()
+	return err
+}
+
+// simulateWeek is SimulateWeek without the persistence. Callers must hold mu.
+func (p *Pyramid) simulateWeek() error {
+	if p.seasonOver() {
+		p.startSeason()
+	}
+	finished := true
+	for _, d := range p.divisions {
+		d.league.mu.Lock()
+		d.league.simulateWeek()
+		if d.league.firstUnplayedWeek() < len(d.league.matches) {
+			finished = false
+		}
+		d.league.mu.Unlock()
+	}
+	if !finished {
+		return nil
+	}
+	return p.endSeason()
+}
+
+// seasonOver reports whether the current season has been settled. Callers
+// must hold mu.
+func (p *Pyramid) seasonOver() bool {
+	return len(p.history) >= p.season
+}
+
+// endSeason plays the playoffs and records the final tables and who goes
+// up and down. The season stays unsettled if a playoff cannot be played.
+// Callers must hold mu.
+func (p *Pyramid) endSeason() error {
+	season := PyramidSeason{Number: p.season}
+	for d := range p.divisions {
+		table := p.divisionTable(d)
+		table.Matches = nil
+		season.Tables = append(season.Tables, table)
+	}
+
+	for d, div := range p.divisions {
+		table := season.Tables[d].Table
+		if d > 0 {
+			for _, standing := range table[:div.promotion] {
+				season.Movements = append(season.Movements, Movement{Team: standing.Team, From: div.name, To: p.divisions[d-1].name})
+			}
+			if div.playoff > 0 {
+				playoff, err := p.playPlayoff(d, table[div.promotion:div.promotion+div.playoff])
+				if err != nil {
+					return err
+				}
+				season.Playoffs = append(season.Playoffs, playoff)
+				season.Movements = append(season.Movements, Movement{Team: playoff.Winner, From: div.name, To: p.divisions[d-1].name, Playoff: true})
+			}
+		}
+		if d < len(p.divisions)-1 {
+			for _, standing := range table[len(table)-div.relegation:] {
+				season.Movements = append(season.Movements, Movement{Team: standing.Team, From: div.name, To: p.divisions[d+1].name})
+			}
+		}
+	}
+	p.history = append(p.history, season)
+	return nil
+}
+
+// playPlayoff plays a one-off seeded knockout between the playoff places of
+// division d, best placed team first. Callers must hold mu.
+func (p *Pyramid) playPlayoff(d int, places []models.Standing) (PyramidPlayoff, error) {
+	teams := make([]models.Team, len(places))
+	for i, standing := range places {
+		teams[i] = standing.Team
+	}
+	seed := rand.New(rand.NewSource(p.seasonSeed(d))).Int63()
+	cup, err := newCup(teams, CupOptions{Seed: &seed, Engine: p.engine})
+	if err != nil {
+		return PyramidPlayoff{}, fmt.Errorf("%s playoff: %w", p.divisions[d].name, err)
+	}
+	cup.rounds = cup.drawBracket()
+	for {
+		_, err := cup.SimulateRound()
+		if errors.Is(err, ErrCupFinished) {
+			break
+		}
+		if err != nil {
+			return PyramidPlayoff{}, fmt.Errorf("%s playoff: %w", p.divisions[d].name, err)
+		}
+	}
+	winner, _ := cup.Champion()
+	return PyramidPlayoff{Division: p.divisions[d].name, Rounds: cup.Bracket(), Winner: winner}, nil
+}
+
+// startSeason applies the last season's movements and gives every division
+// fresh fixtures. Callers must hold mu.
+func (p *Pyramid) startSeason() {
+	moves := p.history[len(p.history)-1].Movements
+	leaving := make(map[int]bool)
+	arriving := make(map[string][]models.Team)
+	for _, move := range moves {
+		leaving[move.Team.ID] = true
+		arriving[move.To] = append(arriving[move.To], move.Team)
+	}
+
+	p.season++
+	for d := range p.divisions {
+		div := &p.divisions[d]
+		var teams []models.Team
+		for _, team := range div.league.Snapshot().Teams {
+			if !leaving[team.ID] {
+				teams = append(teams, team)
+			}
+		}
+		teams = append(teams, arriving[div.name]...)
+		div.league = p.newLeague(d, teams)
+	}
+}
+
+// divisionTable returns division d's current table. Callers must hold mu.
+func (p *Pyramid) divisionTable(d int) DivisionTable {
+	div := p.divisions[d]
+	snapshot := div.league.Snapshot()
+	return DivisionTable{
+		Name:        div.name,
+		Promotion:   div.promotion,
+		Playoff:     div.playoff,
+		Relegation:  div.relegation,
+		Table:       snapshot.Table,
+		Matches:     snapshot.Matches,
+		CurrentWeek: snapshot.CurrentWeek,
+	}
+}
+
+// ID returns the pyramid's registry ID.
+func (p *Pyramid) ID() int {
+	return p.id
+}
+
+// Name returns the pyramid's display name.
+func (p *Pyramid) Name() string {
+	return p.name
+}
+
+// Seed returns the seed every season is derived from.
+func (p *Pyramid) Seed() int64 {
+	return p.seed
+}
+
+// Engine returns the match engine every division plays with.
+func (p *Pyramid) Engine() MatchEngine {
+	return p.engine
+}
+
+// Tiebreakers returns the rules every table is ranked with.
+func (p *Pyramid) Tiebreakers() []Tiebreaker {
+	return p.tiebreakers
+}
+
+// Season returns the current season, counted from 1, and whether it has
+// been settled.
+func (p *Pyramid) Season() (int, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.season, p.seasonOver()
+}
+
+// Divisions returns every division's current table, top tier first.
+func (p *Pyramid) Divisions() []DivisionTable {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	tables := make([]DivisionTable, len(p.divisions))
+	for d := range p.divisions {
+		tables[d] = p.divisionTable(d)
+	}
+	return tables
+}
+
+// Seasons returns every settled season, oldest first.
+func (p *Pyramid) Seasons() []PyramidSeason {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return append([]PyramidSeason(nil), p.history...)
+}
+
+// state copies the pyramid into a PyramidState. Callers must hold mu.
+func (p *Pyramid) state() PyramidState {
+	state := PyramidState{
+		ID:          p.id,
+		Name:        p.name,
+		Seed:        p.seed,
+		Engine:      EngineName(p.engine),
+		Tiebreakers: TiebreakerNames(p.tiebreakers),
+		Season:      p.season,
+		History:     append([]PyramidSeason(nil), p.history...),
+	}
+	for _, div := range p.divisions {
+		div.league.mu.RLock()
+		state.Divisions = append(state.Divisions, PyramidDivisionState{
+			Name:       div.name,
+			Promotion:  div.promotion,
+			Playoff:    div.playoff,
+			Relegation: div.relegation,
+			League:     div.league.state(),
+		})
+		div.league.mu.RUnlock()
+	}
+	return state
+}
+
+// persist writes the pyramid through to the repository, if one is
+// configured. Callers must hold mu.
+func (p *Pyramid) persist() {
+	if p.repo == nil {
+		return
+	}
+	if err := p.repo.SavePyramid(p.state()); err != nil {
+		log.Printf("failed to persist pyramid %d: %v", p.id, err)
+	}
+}
+
+// detach stops the pyramid writing through to its repository.
+func (p *Pyramid) detach() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.repo = nil
+}
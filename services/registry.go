@@ -0,0 +1,481 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrLeagueNotFound is returned when no league has the requested ID.
+	ErrLeagueNotFound = errors.New("league not found")
+	// ErrInvalidTeams is returned when a team list cannot form a league.
+	ErrInvalidTeams = errors.New("invalid team list")
+)
+
+// Registry owns every league, cup, tournament and pyramid served by the API,
+// from creation to deletion. All are written through to the repository when
+// one is configured.
+type Registry struct {
+	mu               sync.RWMutex
+	leagues          map[int]*SimulatorImpl
+	nextID           int
+	cups             map[int]*Cup
+	nextCupID        int
+	tournaments      map[int]*Tournament
+	nextTournamentID int
+	pyramids         map[int]*Pyramid
+	nextPyramidID    int
+	repo             Repository
+	feed             *Feed
+	opts             []SimulatorOption
+}
+
+// NewRegistry returns an empty registry. opts are applied to every league it
+// creates or restores; repo may be nil to keep leagues in memory only.
+func NewRegistry(repo Repository, opts ...SimulatorOption) *Registry {
+	return &Registry{
+		leagues:          make(map[int]*SimulatorImpl),
+		nextID:           1,
+		cups:             make(map[int]*Cup),
+		nextCupID:        1,
+		tournaments:      make(map[int]*Tournament),
+		nextTournamentID: 1,
+		pyramids:         make(map[int]*Pyramid),
+		nextPyramidID:    1,
+		repo:             repo,
+		feed:             NewFeed(DefaultFeedBacklog),
+		opts:             opts,
+	}
+}
+
+// Feed returns the live feed every league in the registry publishes to.
+func (r *Registry) Feed() *Feed {
+	return r.feed
+}
+
+// Load restores everything stored in the repository.
+func (r *Registry) Load() error {
+	if r.repo == nil {
+		return nil
+	}
+	states, err := r.repo.LoadLeagues()
+	if err != nil {
+		return err
+	}
+	cups, err := r.repo.LoadCups()
+	if err != nil {
+		return err
+	}
+	tournaments, err := r.repo.LoadTournaments()
+	if err != nil {
+		return err
+	}
+	pyramids, err := r.repo.LoadPyramids()
+	if err != nil {
+		return err
+	}
+	next, err := r.repo.LoadNextIDs()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID = max(r.nextID, next[IDLeagues])
+	r.nextCupID = max(r.nextCupID, next[IDCups])
+	r.nextTournamentID = max(r.nextTournamentID, next[IDTournaments])
+	r.nextPyramidID = max(r.nextPyramidID, next[IDPyramids])
+	for _, state := range states {
+		s := restoreSimulator(state, r.opts...)
+		s.repo = r.repo
+		s.feed = r.feed
+		r.leagues[s.id] = s
+		if s.id >= r.nextID {
+			r.nextID = s.id + 1
+		}
+	}
+	for _, state := range cups {
+		c := restoreCup(state)
+		c.repo = r.repo
+		r.cups[c.id] = c
+		if c.id >= r.nextCupID {
+			r.nextCupID = c.id + 1
+		}
+	}
+	for _, state := range tournaments {
+		t := restoreTournament(state)
+		t.repo = r.repo
+		r.tournaments[t.id] = t
+		if t.id >= r.nextTournamentID {
+			r.nextTournamentID = t.id + 1
+		}
+	}
+	for _, state := range pyramids {
+		p := restorePyramid(state)
+		p.repo = r.repo
+		r.pyramids[p.id] = p
+		if p.id >= r.nextPyramidID {
+			r.nextPyramidID = p.id + 1
+		}
+	}
+	return nil
+}
+
+// Create registers a new league built from teams. Teams without an ID are
+// numbered after the highest ID in the list.
+func (r *Registry) Create(name string, teams []models.Team, opts ...SimulatorOption) (LeagueSimulator, error) {
+	teams, err := normaliseTeams(teams)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := newSimulator(teams, append(append([]SimulatorOption(nil), r.opts...), opts...)...)
+	id, err := r.reserveID(IDLeagues, &r.nextID)
+	if err != nil {
+		return nil, err
+	}
+	s.id = id
+	s.name = name
+	if s.name == "" {
+		s.name = fmt.Sprintf("League %d", s.id)
+	}
+	if r.repo != nil {
+		if err := r.repo.SaveLeague(s.state()); err != nil {
+			return nil, err
+		}
+		s.repo = r.repo
+	}
+	s.feed = r.feed
+
+	r.leagues[s.id] = s
+	return s, nil
+}
+
+// reserveID hands out the next ID from counter and, when a repository is
+// configured, stores the counter first so the ID is never given out again.
+// The caller must hold r.mu.
+func (r *Registry) reserveID(kind string, counter *int) (int, error) {
+	id := *counter
+	if r.repo != nil {
+		if err := r.repo.SaveNextID(kind, id+1); err != nil {
+			return 0, err
+		}
+	}
+	*counter = id + 1
+	return id, nil
+}
+
+// Get returns the league with the given ID.
+func (r *Registry) Get(id int) (LeagueSimulator, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.leagues[id]
+	if !ok {
+		return nil, ErrLeagueNotFound
+	}
+	return s, nil
+}
+
+// List returns every league ordered by ID.
+func (r *Registry) List() []LeagueSimulator {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]int, 0, len(r.leagues))
+	for id := range r.leagues {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	leagues := make([]LeagueSimulator, 0, len(ids))
+	for _, id := range ids {
+		leagues = append(leagues, r.leagues[id])
+	}
+	return leagues
+}
+
+// Delete removes a league from the registry and the repository.
+func (r *Registry) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.leagues[id]
+	if !ok {
+		return ErrLeagueNotFound
+	}
+	if r.repo != nil {
+		if err := r.repo.DeleteLeague(id); err != nil {
+			return err
+		}
+	}
+	s.detach()
+	delete(r.leagues, id)
+	return nil
+}
+
+// CreateCup registers a new knockout cup drawn from teams; see NewCup.
+func (r *Registry) CreateCup(name string, teams []models.Team, opts CupOptions) (*Cup, error) {
+	c, err := NewCup(teams, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := r.reserveID(IDCups, &r.nextCupID)
+	if err != nil {
+		return nil, err
+	}
+	c.id = id
+	c.name = name
+	if c.name == "" {
+		c.name = fmt.Sprintf("Cup %d", c.id)
+	}
+	if r.repo != nil {
+		if err := r.repo.SaveCup(c.state()); err != nil {
+			return nil, err
+		}
+		c.repo = r.repo
+	}
+
+	r.cups[c.id] = c
+	return c, nil
+}
+
+// GetCup returns the cup with the given ID.
+func (r *Registry) GetCup(id int) (*Cup, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.cups[id]
+	if !ok {
+		return nil, ErrCupNotFound
+	}
+	return c, nil
+}
+
+// ListCups returns every cup ordered by ID.
+func (r *Registry) ListCups() []*Cup {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]int, 0, len(r.cups))
+	for id := range r.cups {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	cups := make([]*Cup, 0, len(ids))
+	for _, id := range ids {
+		cups = append(cups, r.cups[id])
+	}
+	return cups
+}
+
+// DeleteCup removes a cup from the registry and the repository.
+func (r *Registry) DeleteCup(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.cups[id]
+	if !ok {
+		return ErrCupNotFound
+	}
+	if r.repo != nil {
+		if err := r.repo.DeleteCup(id); err != nil {
+			return err
+		}
+	}
+	c.detach()
+	delete(r.cups, id)
+	return nil
+}
+
+// CreateTournament registers a new tournament drawn from entrants; see
+// NewTournament.
+func (r *Registry) CreateTournament(name string, entrants []Entrant, opts TournamentOptions) (*Tournament, error) {
+	t, err := NewTournament(entrants, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := r.reserveID(IDTournaments, &r.nextTournamentID)
+	if err != nil {
+		return nil, err
+	}
+	t.id = id
+	t.name = name
+	if t.name == "" {
+		t.name = fmt.Sprintf("Tournament %d", t.id)
+	}
+	if r.repo != nil {
+		if err := r.repo.SaveTournament(t.state()); err != nil {
+			return nil, err
+		}
+		t.repo = r.repo
+	}
+
+	r.tournaments[t.id] = t
+	return t, nil
+}
+
+// GetTournament returns the tournament with the given ID.
+func (r *Registry) GetTournament(id int) (*Tournament, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	t, ok := r.tournaments[id]
+	if !ok {
+		return nil, ErrTournamentNotFound
+	}
+	return t, nil
+}
+
+// ListTournaments returns every tournament ordered by ID.
+func (r *Registry) ListTournaments() []*Tournament {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]int, 0, len(r.tournaments))
+	for id := range r.tournaments {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	tournaments := make([]*Tournament, 0, len(ids))
+	for _, id := range ids {
+		tournaments = append(tournaments, r.tournaments[id])
+	}
+	return tournaments
+}
+
+// DeleteTournament removes a tournament from the registry and the repository.
+func (r *Registry) DeleteTournament(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t, ok := r.tournaments[id]
+	if !ok {
+		return ErrTournamentNotFound
+	}
+	if r.repo != nil {
+		if err := r.repo.DeleteTournament(id); err != nil {
+			return err
+		}
+	}
+	t.detach()
+	delete(r.tournaments, id)
+	return nil
+}
+
+// CreatePyramid registers a new pyramid built from divisions; see NewPyramid.
+func (r *Registry) CreatePyramid(name string, divisions []DivisionConfig, opts PyramidOptions) (*Pyramid, error) {
+	p, err := NewPyramid(divisions, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, err := r.reserveID(IDPyramids, &r.nextPyramidID)
+	if err != nil {
+		return nil, err
+	}
+	p.id = id
+	p.name = name
+	if p.name == "" {
+		p.name = fmt.Sprintf("Pyramid %d", p.id)
+	}
+	if r.repo != nil {
+		if err := r.repo.SavePyramid(p.state()); err != nil {
+			return nil, err
+		}
+		p.repo = r.repo
+	}
+
+	r.pyramids[p.id] = p
+	return p, nil
+}
+
+// GetPyramid returns the pyramid with the given ID.
+func (r *Registry) GetPyramid(id int) (*Pyramid, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.pyramids[id]
+	if !ok {
+		return nil, ErrPyramidNotFound
+	}
+	return p, nil
+}
+
+// ListPyramids returns every pyramid ordered by ID.
+func (r *Registry) ListPyramids() []*Pyramid {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]int, 0, len(r.pyramids))
+	for id := range r.pyramids {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	pyramids := make([]*Pyramid, 0, len(ids))
+	for _, id := range ids {
+		pyramids = append(pyramids, r.pyramids[id])
+	}
+	return pyramids
+}
+
+// DeletePyramid removes a pyramid from the registry and the repository.
+func (r *Registry) DeletePyramid(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.pyramids[id]
+	if !ok {
+		return ErrPyramidNotFound
+	}
+	if r.repo != nil {
+		if err := r.repo.DeletePyramid(id); err != nil {
+			return err
+		}
+	}
+	p.detach()
+	delete(r.pyramids, id)
+	return nil
+}
+
+// normaliseTeams validates a team list and fills in missing IDs.
+func normaliseTeams(teams []models.Team) ([]models.Team, error) {
+	if len(teams) < 2 {
+		return nil, fmt.Errorf("%w: at least 2 teams are required", ErrInvalidTeams)
+	}
+
+	normalised := make([]models.Team, len(teams))
+	copy(normalised, teams)
+
+	maxID := 0
+	for _, team := range normalised {
+		if team.ID > maxID {
+			maxID = team.ID
+		}
+	}
+	seen := make(map[int]bool)
+	for i := range normalised {
+		team := &normalised[i]
+		if err := validateTeam(team); err != nil {
+			return nil, err
+		}
+		if team.ID == 0 {
+			maxID++
+			team.ID = maxID
+		}
+		if seen[team.ID] {
+			return nil, fmt.Errorf("%w: duplicate team ID %d", ErrInvalidTeams, team.ID)
+		}
+		seen[team.ID] = true
+	}
+	return normalised, nil
+}
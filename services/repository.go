@@ -0,0 +1,75 @@
+package services
+
+import "league-simulator/models"
+
+// Repository persists leagues, cups, tournaments and pyramids so they survive
+// restarts.
+type Repository interface {
+	// LoadLeagues returns every stored league.
+	LoadLeagues() ([]LeagueState, error)
+	// SaveLeague replaces the stored copy of the league with state.ID.
+	SaveLeague(state LeagueState) error
+	// DeleteLeague removes a league and everything that belongs to it.
+	DeleteLeague(id int) error
+	// LoadCups returns every stored cup.
+	LoadCups() ([]CupState, error)
+	// SaveCup replaces the stored copy of the cup with state.ID.
+	SaveCup(state CupState) error
+	// DeleteCup removes a cup.
+	DeleteCup(id int) error
+	// LoadTournaments returns every stored tournament.
+	LoadTournaments() ([]TournamentState, error)
+	// SaveTournament replaces the stored copy of the tournament with state.ID.
+	SaveTournament(state TournamentState) error
+	// DeleteTournament removes a tournament.
+	DeleteTournament(id int) error
+	// LoadPyramids returns every stored pyramid.
+	LoadPyramids() ([]PyramidState, error)
+	// SavePyramid replaces the stored copy of the pyramid with state.ID.
+	SavePyramid(state PyramidState) error
+	// DeletePyramid removes a pyramid.
+	DeletePyramid(id int) error
+	// LoadNextIDs returns the stored next ID of each kind of record, keyed
+	// by IDLeagues, IDCups, IDTournaments and IDPyramids.
+	LoadNextIDs() (map[string]int, error)
+	// SaveNextID stores the next ID to give out for a kind of record.
+	SaveNextID(kind string, next int) error
+}
+
+// Kinds of record with their own ID counter. The registry never gives out
+// an ID twice, even after the record is deleted and the server restarted,
+// so old references cannot resolve to a different record.
+const (
+	IDLeagues     = "leagues"
+	IDCups        = "cups"
+	IDTournaments = "tournaments"
+	IDPyramids    = "pyramids"
+)
+
+// LeagueState is everything needed to restore a SimulatorImpl.
+type LeagueState struct {
+	ID          int
+	Name        string
+	Teams       []models.Team
+	Players     []models.Player
+	Matches     [][]models.Match
+	Standings   map[int]*models.Standing
+	CurrentWeek int
+	Seed        int64
+	Tiebreakers []string
+	// Engine is the EngineName of the league's match engine.
+	Engine  string
+	History []HistoryEvent
+	// Cursor is the number of History events currently applied.
+	Cursor int
+	// Season is the current season; Seasons are the archived ones.
+	Season  int
+	Seasons []SeasonRecord
+	// Remainders is what rounding left out of each team's developed ratings,
+	// by team ID.
+	Remainders map[int]RatingRemainder
+	// Playoff is nil when the league has no playoff.
+	Playoff *PlayoffState
+	// NextPlayerID is the ID AddPlayer gives the next player without one.
+	NextPlayerID int
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrSeasonInProgress is returned by NextSeason while matches are left to play.
+	ErrSeasonInProgress = errors.New("season in progress")
+	// ErrSeasonNotFound is returned for a season the league has not reached.
+	ErrSeasonNotFound = errors.New("season not found")
+)
+
+// Between seasons each team's strength moves towards a target made of three
+// parts, each a share of the league's mean strength: a reward for finishing
+// high (the champion gains developmentPosition, the bottom team loses it), a
+// pull developmentRegression of the way back to the mean, and a random
+// development factor with standard deviation developmentSpread.
+const (
+	developmentPosition   = 0.10
+	developmentRegression = 0.25
+	developmentSpread     = 0.08
+	// developmentSalt keeps the development draws apart from the match
+	// draws of the season seeded with the same value.
+	developmentSalt = 0x5eed
+)
+
+// SeasonRecord is an archived season: the final table, the results, the
+// playoff if the league had one, and how each team's strength changed going
+// into the next season.
+type SeasonRecord struct {
+	Number  int
+	Seed    int64
+	Table   []models.Standing
+	Matches [][]models.Match
+	Playoff *Playoff
+	Changes []StrengthChange
+}
+
+// RatingRemainder is the part of a team's developed ratings that rounding to
+// whole points left out. It is added back at the next development, so
+// changes of less than half a point still add up over the seasons.
+type RatingRemainder struct {
+	Strength float64
+	Attack   float64
+	Defence  float64
+}
+
+// StrengthChange is one team's development between two seasons. Team is the
+// team as it played the archived season.
+type StrengthChange struct {
+	Team   models.Team
+	Before int
+	After  int
+}
+
+// Season returns the number of the league's current season, starting at 1.
+func (s *SimulatorImpl) Season() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.season
+}
+
+// Seasons returns the archived seasons, oldest first.
+func (s *SimulatorImpl) Seasons() []SeasonRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]SeasonRecord(nil), s.seasons...)
+}
+
+// NextSeason archives the finished season and starts the next one with the
+// same teams, their strengths developed by evolveTeams, and fresh fixtures.
+// A configured playoff must have been decided and carries over to the next
+// season. It returns the archived season.
+func (s *SimulatorImpl) NextSeason() (SeasonRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.firstUnplayedWeek() < len(s.matches) {
+		return SeasonRecord{}, fmt.Errorf("%w: season %d still has matches to play", ErrSeasonInProgress, s.season)
+	}
+	var playoff *Playoff
+	if s.playoffConfig != nil {
+		current := s.currentPlayoff()
+		if !current.Decided() {
+			return SeasonRecord{}, fmt.Errorf("%w: the %s of season %d have not been decided", ErrSeasonInProgress, current.Config.Name, s.season)
+		}
+		playoff = &current
+	}
+
+	record := SeasonRecord{
+		Number:  s.season,
+		Seed:    s.seasonSeed(),
+		Table:   s.sortedStandings(),
+		Matches: archivedResults(s.matches),
+		Playoff: playoff,
+	}
+	rng := rand.New(rand.NewSource(s.seasonSeed() ^ developmentSalt))
+	s.teams, record.Changes, s.remainders = evolveTeams(s.teams, record.Table, s.remainders, rng)
+	s.seasons = append(s.seasons, record)
+
+	s.season++
+	s.regenerateFixtures()
+	s.publishStandings()
+	s.persist()
+	return record, nil
+}
+
+// seasonSeed is the seed the current season's weeks are derived from: the
+// league seed in the first season and one more in each season after.
+// Callers must hold mu.
+func (s *SimulatorImpl) seasonSeed() int64 {
+	return s.seed + int64(s.season-1)
+}
+
+// archivedResults copies the results without their timelines, which would
+// otherwise make up most of an archive.
+func archivedResults(matches [][]models.Match) [][]models.Match {
+	archived := make([][]models.Match, len(matches))
+	for i, weekMatches := range matches {
+		archived[i] = make([]models.Match, len(weekMatches))
+		for j, match := range weekMatches {
+			match.Events = nil
+			archived[i][j] = match
+		}
+	}
+	return archived
+}
+
+// evolveTeams develops every team's strength for the next season from its
+// position in table. Attack and defence ratings, where set, change in the
+// same proportion. Each rating develops from its exact value, the whole
+// points plus the remainder left from the last development, and the new
+// remainders are returned with the teams, which keep their original order.
+func evolveTeams(teams []models.Team, table []models.Standing, remainders map[int]RatingRemainder, rng *rand.Rand) ([]models.Team, []StrengthChange, map[int]RatingRemainder) {
+	mean := 0.0
+	for _, team := range teams {
+		mean += strengthRating(team)
+	}
+	mean /= float64(len(teams))
+
+	developed := make(map[int]models.Team, len(teams))
+	left := make(map[int]RatingRemainder, len(teams))
+	changes := make([]StrengthChange, 0, len(table))
+	for i, standing := range table {
+		team := standing.Team
+		// 1 for the champion down to -1 for the bottom team
+		performance := 0.0
+		if len(table) > 1 {
+			performance = 1 - 2*float64(i)/float64(len(table)-1)
+		}
+		carried := remainders[team.ID]
+		strength := math.Max(float64(team.Strength)+carried.Strength, 1)
+		target := strength +
+			developmentPosition*performance*mean +
+			developmentRegression*(mean-strength) +
+			developmentSpread*mean*rng.NormFloat64()
+		ratio := math.Max(target, 1) / strength
+
+		next := team
+		var remainder RatingRemainder
+		next.Strength, remainder.Strength = developRating(strength, ratio)
+		if team.Attack > 0 {
+			next.Attack, remainder.Attack = developRating(float64(team.Attack)+carried.Attack, ratio)
+		}
+		if team.Defence > 0 {
+			next.Defence, remainder.Defence = developRating(float64(team.Defence)+carried.Defence, ratio)
+		}
+		developed[team.ID] = next
+		left[team.ID] = remainder
+		changes = append(changes, StrengthChange{Team: team, Before: team.Strength, After: next.Strength})
+	}
+
+	evolved := make([]models.Team, len(teams))
+	for i, team := range teams {
+		evolved[i] = developed[team.ID]
+	}
+	return evolved, changes, left
+}
+
+// developRating scales a rating, keeping it between 1 and MaxTeamRating, and
+// returns it rounded to whole points along with what the rounding left out.
+func developRating(rating, ratio float64) (int, float64) {
+	exact := math.Min(math.Max(rating*ratio, 1), MaxTeamRating)
+	rounded := math.Round(exact)
+	return int(rounded), exact - rounded
+}
+
+// copyRemainders copies a league's rating remainders for saving.
+func copyRemainders(remainders map[int]RatingRemainder) map[int]RatingRemainder {
+	if remainders == nil {
+		return nil
+	}
+	copied := make(map[int]RatingRemainder, len(remainders))
+	for id, remainder := range remainders {
+		copied[id] = remainder
+	}
+	return copied
+}
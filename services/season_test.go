@@ -0,0 +1,61 @@
+package services
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"league-simulator/models"
+)
+
+func TestDevelopmentAddsUpOverSeasons(t *testing.T) {
+	// Two gains of 0.3 points each round away on their own, but not together.
+	first, remainder := developRating(6, 6.3/6)
+	if first != 6 || math.Abs(remainder-0.3) > 1e-9 {
+		t.Fatalf("6 × 1.05 gave %d with remainder %.2f, want 6 and 0.30", first, remainder)
+	}
+	if second, _ := developRating(float64(first)+remainder, 6.6/6.3); second != 7 {
+		t.Errorf("the second 0.3 gain gave %d, want 7", second)
+	}
+
+	// The same team wins every season and another finishes bottom; both must
+	// move away from the pack, not just wobble with the random factor.
+	teams := make([]models.Team, 6)
+	for i := range teams {
+		teams[i] = models.Team{ID: i + 1, Name: "Team", Strength: 6}
+	}
+	rng := rand.New(rand.NewSource(1))
+	var remainders map[int]RatingRemainder
+	for season := 0; season < 10; season++ {
+		table := make([]models.Standing, len(teams))
+		for i, team := range teams {
+			table[i] = models.Standing{Team: team}
+		}
+		teams, _, remainders = evolveTeams(teams, table, remainders, rng)
+		for _, team := range teams {
+			if r := remainders[team.ID].Strength; math.Abs(r) > 0.5+1e-9 {
+				t.Fatalf("season %d: team %d carries %.2f, more than rounding can leave", season+1, team.ID, r)
+			}
+		}
+	}
+	champion, bottom := teams[0].Strength, teams[len(teams)-1].Strength
+	if champion <= 6 || bottom >= 6 {
+		t.Errorf("after 10 seasons the champion has %d and the bottom side %d, want above and below 6", champion, bottom)
+	}
+}
+
+func TestSeededSimulateEchoesTheSeasonSeed(t *testing.T) {
+	s := newSimulator(testTeams(4), WithSeed(40))
+	s.SimulateAll()
+	if _, err := s.NextSeason(); err != nil {
+		t.Fatal(err)
+	}
+
+	seeds, err := s.SimulateWeekSeeded(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seeds.League != 40 || seeds.Season != 41 {
+		t.Errorf("season 2 echoed %+v, want league seed 40 and season seed 41", seeds)
+	}
+}
@@ -1,150 +1,423 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"league-simulator/models"
+	"log"
 	"math/rand"
-	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMatchNotFound is returned when no match has the requested ID.
+	ErrMatchNotFound = errors.New("match not found")
+	// ErrInvalidScore is returned for negative goal counts.
+	ErrInvalidScore = errors.New("goals must be zero or more")
+	// ErrSeasonFinished is returned by SimulateWeek once every match is played.
+	ErrSeasonFinished = errors.New("season finished: no matches left to simulate")
 )
 
 // LeagueSimulator defines the interface for the league simulation.
 type LeagueSimulator interface {
-	SimulateWeek() bool
+	SimulateWeek() error
 	SimulateAll()
+	SimulateWeekSeeded(seed *int64) (Seeds, error)
+	SimulateAllSeeded(seed *int64) Seeds
 	GetStandings() []models.Standing
 	Matches() [][]models.Match
 	StandingsCopy() map[int]*models.Standing
 	EditMatchResult(matchID, homeGoals, awayGoals int) error
+	ClearMatchResult(matchID int) error
 	RecalculateStandings()
 	GetMatchByID(matchID int) (*models.Match, error)
 	Reset()
+	Seeds() Seeds
+	Reseed(seed int64)
+	Engine() MatchEngine
+	Snapshot() LeagueSnapshot
+	ID() int
+	Name() string
+	Tiebreakers() []Tiebreaker
+	Teams() []models.Team
+	AddTeam(team models.Team) (models.Team, error)
+	UpdateTeam(teamID int, update TeamUpdate) (models.Team, error)
+	RemoveTeam(teamID int) error
+	History() ([]HistoryEvent, int)
+	Undo() (HistoryEvent, error)
+	Redo() (HistoryEvent, error)
+	Elo() *EloSystem
+	Ratings() map[int]float64
+	RatingHistory() RatingHistory
+	Players(teamID int) ([]models.Player, error)
+	Player(playerID int) (models.Player, error)
+	AddPlayer(player models.Player) (models.Player, error)
+	UpdatePlayer(playerID int, update PlayerUpdate) (models.Player, error)
+	RemovePlayer(playerID int) error
+	PlayerStats() []PlayerStats
+	Season() int
+	Seasons() []SeasonRecord
+	NextSeason() (SeasonRecord, error)
+	SetPlayoff(config PlayoffConfig) (PlayoffConfig, error)
+	RemovePlayoff() error
+	Playoff() (Playoff, error)
+	SimulatePlayoffRound() (CupRound, error)
+	SimulatePlayoff() (Playoff, error)
 }
 
-// SimulatorImpl implements the LeagueSimulator interface.
+// LeagueSnapshot is a consistent copy of a league's state taken under a
+// single lock, so readers never observe a half-simulated week.
+type LeagueSnapshot struct {
+	Teams       []models.Team
+	Matches     [][]models.Match
+	Standings   map[int]*models.Standing
+	Table       []models.Standing // Standings in ranked order
+	CurrentWeek int
+	Seed        int64
+	Season      int
+	// Ratings are the Elo ratings after the last played week, by team ID.
+	Ratings map[int]float64
+}
+
+// SimulatorImpl implements the LeagueSimulator interface. It is safe for
+// concurrent use; every exported method holds mu for its whole duration.
 
 type SimulatorImpl struct {
+	mu          sync.RWMutex
+	id          int
+	name        string
 	teams       []models.Team
+	players     []models.Player
 	matches     [][]models.Match
 	standings   map[int]*models.Standing
 	currentWeek int
+	engine      MatchEngine
+	seed        int64
+	repo        Repository
+	feed        *Feed
+	tiebreakers []Tiebreaker
+	history     []HistoryEvent
+	cursor      int
+	elo         *EloSystem
+	// ratings holds the Elo ratings before week 1 and after every week
+	// played so far; see EloSystem.Replay.
+	ratings []map[int]float64
+	season  int
+	seasons []SeasonRecord
+	// remainders holds, per team ID, what rounding left out of the ratings
+	// developed at the last season change.
+	remainders map[int]RatingRemainder
+	// playoff is drawn from the final table when the playoff starts and
+	// dropped whenever the results change.
+	playoffConfig *PlayoffConfig
+	playoff       *Cup
+	// nextPlayerID is the ID of the next player added without one. It only
+	// grows, so a removed player's ID is never given out again.
+	nextPlayerID int
+}
+
+// SimulatorOption configures a SimulatorImpl at construction time.
+type SimulatorOption func(*SimulatorImpl)
+
+// WithSeed makes the simulation reproducible by fixing the league's seed.
+func WithSeed(seed int64) SimulatorOption {
+	return func(s *SimulatorImpl) {
+		s.seed = seed
+	}
+}
+
+// WithName sets the league's display name.
+func WithName(name string) SimulatorOption {
+	return func(s *SimulatorImpl) {
+		s.name = name
+	}
+}
+
+// WithTiebreakers sets the chain of rules used to rank the table.
+func WithTiebreakers(rules []Tiebreaker) SimulatorOption {
+	return func(s *SimulatorImpl) {
+		s.tiebreakers = rules
+	}
 }
 
-func NewSimulator(teams []models.Team) LeagueSimulator {
+// WithMatchEngine sets the engine used to decide match scores.
+func WithMatchEngine(engine MatchEngine) SimulatorOption {
+	return func(s *SimulatorImpl) {
+		s.engine = engine
+	}
+}
+
+func NewSimulator(teams []models.Team, opts ...SimulatorOption) LeagueSimulator {
+	return newSimulator(teams, opts...)
+}
+
+// restoreSimulator rebuilds a league from saved state. Weeks left to play
+// are drawn from the saved seed as they would have been without a restart.
+func restoreSimulator(state LeagueState, opts ...SimulatorOption) *SimulatorImpl {
+	s := newSimulator(state.Teams, opts...)
+	s.id = state.ID
+	s.name = state.Name
+	s.players = state.Players
+	s.nextPlayerID = max(state.NextPlayerID, 1)
+	for _, player := range s.players {
+		s.nextPlayerID = max(s.nextPlayerID, player.ID+1)
+	}
+	s.matches = state.Matches
+	s.currentWeek = state.CurrentWeek
+	s.history = state.History
+	s.cursor = state.Cursor
+	s.seed = state.Seed
+	s.season = max(state.Season, 1)
+	s.seasons = state.Seasons
+	s.remainders = state.Remainders
+	s.restorePlayoff(state.Playoff)
+	if engine, err := ParseEngine(state.Engine); err == nil {
+		s.engine = engine
+	}
+	if len(state.Tiebreakers) > 0 {
+		if rules, err := ParseTiebreakers(state.Tiebreakers); err == nil {
+			s.tiebreakers = rules
+		}
+	}
+	s.recalculateStandings()
+	return s
+}
+
+func newSimulator(teams []models.Team, opts ...SimulatorOption) *SimulatorImpl {
 	standings := make(map[int]*models.Standing)
 	for _, team := range teams {
 		standings[team.ID] = &models.Standing{Team: team}
 	}
 
-	return &SimulatorImpl{
+	s := &SimulatorImpl{
 		teams:       teams,
 		standings:   standings,
 		matches:     generateFixtures(teams),
 		currentWeek: 0,
+		engine:      NewPoissonEngine(),
+		seed:        time.Now().UnixNano(),
+		tiebreakers: DefaultTiebreakers,
+		elo:         NewEloSystem(),
+		season:      1,
 	}
+	s.nextPlayerID = 1
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.recalculateRatings()
+	return s
 }
+
+// generateFixtures builds a double round robin with the circle method. With
+// an odd number of teams a bye slot is added and whoever is drawn against it
+// rests that week. The second half repeats the first with home and away
+// swapped, so every team hosts each opponent exactly once.
 func generateFixtures(teams []models.Team) [][]models.Match {
 	numTeams := len(teams)
-	weeks := (numTeams - 1) * 2
-	fixtures := make([][]models.Match, weeks)
-	matchID := 1
+	if numTeams < 2 {
+		return nil
+	}
 
-	// Takımların sırasını değiştirmemek için kopyasını al
-	teamList := make([]models.Team, numTeams)
-	copy(teamList, teams)
+	// Rotate indexes so the teams keep their order. The bye slot (-1) takes
+	// the fixed first slot, so the rotating teams still get an even split of
+	// home and away matches.
+	slots := make([]int, 0, numTeams+1)
+	if numTeams%2 != 0 {
+		slots = append(slots, -1)
+	}
+	for i := range teams {
+		slots = append(slots, i)
+	}
+	numSlots := len(slots)
+	rounds := numSlots - 1
+	fixtures := make([][]models.Match, rounds*2)
+	matchID := 1
 
-	// İlk devre
-	for w := 0; w < numTeams-1; w++ {
+	// First half of the season
+	for w := 0; w < rounds; w++ {
 		var weekMatches []models.Match
-		for i := 0; i < numTeams/2; i++ {
-			home := teamList[i]
-			away := teamList[numTeams-1-i]
+		for i := 0; i < numSlots/2; i++ {
+			home, away := slots[i], slots[numSlots-1-i]
+			if home < 0 || away < 0 {
+				continue // bay
+			}
+			// The fixed slot alternates every week; the others alternate by
+			// pair so nobody plays a long run of home or away games.
+			if (i == 0 && w%2 == 1) || (i > 0 && i%2 == 1) {
+				home, away = away, home
+			}
 			weekMatches = append(weekMatches, models.Match{
 				ID:     matchID,
-				Home:   home,
-				Away:   away,
+				Home:   teams[home],
+				Away:   teams[away],
 				Week:   w + 1,
 				Played: false,
 			})
 			matchID++
 		}
 		fixtures[w] = weekMatches
-		teamList = append([]models.Team{teamList[0]}, append(teamList[numTeams-1:], teamList[1:numTeams-1]...)...)
+		// Rotate every slot except the first one clockwise
+		slots = append([]int{slots[0], slots[numSlots-1]}, slots[1:numSlots-1]...)
 	}
 
-	// İkinci devre (ev-deplasman değişimi)
-	teamList2 := make([]models.Team, numTeams)
-	copy(teamList2, teams)
-	for w := 0; w < numTeams-1; w++ {
-		var weekMatches []models.Match
-		for i := 0; i < numTeams/2; i++ {
-			home := teamList2[numTeams-1-i]
-			away := teamList2[i]
+	// Second half of the season (home and away swapped)
+	for w := 0; w < rounds; w++ {
+		weekMatches := make([]models.Match, 0, len(fixtures[w]))
+		for _, first := range fixtures[w] {
 			weekMatches = append(weekMatches, models.Match{
 				ID:     matchID,
-				Home:   home,
-				Away:   away,
-				Week:   w + numTeams,
+				Home:   first.Away,
+				Away:   first.Home,
+				Week:   w + rounds + 1,
 				Played: false,
 			})
 			matchID++
 		}
-		fixtures[w+numTeams-1] = weekMatches
-		teamList2 = append([]models.Team{teamList2[0]}, append(teamList2[numTeams-1:], teamList2[1:numTeams-1]...)...)
+		fixtures[w+rounds] = weekMatches
 	}
 
 	return fixtures
 }
 
-func (s *SimulatorImpl) SimulateWeek() bool {
+// RestingTeams returns the teams that have no match in weekMatches, i.e. the
+// team on a bye when the league has an odd number of teams.
+func RestingTeams(teams []models.Team, weekMatches []models.Match) []models.Team {
+	playing := make(map[int]bool, len(weekMatches)*2)
+	for _, match := range weekMatches {
+		playing[match.Home.ID] = true
+		playing[match.Away.ID] = true
+	}
+	var resting []models.Team
+	for _, team := range teams {
+		if !playing[team.ID] {
+			resting = append(resting, team)
+		}
+	}
+	return resting
+}
+
+// SimulateWeek plays the next week, or returns ErrSeasonFinished when no
+// matches are left.
+func (s *SimulatorImpl) SimulateWeek() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.simulateWeek() {
+		return ErrSeasonFinished
+	}
+	s.persist()
+	return nil
+}
+
+// simulateWeek plays the unplayed matches of the earliest week that still
+// has any. Matches already given a result, e.g. by an edit, are kept.
+// Callers must hold mu.
+func (s *SimulatorImpl) simulateWeek() bool {
+	s.currentWeek = s.firstUnplayedWeek()
 	if s.currentWeek >= len(s.matches) {
-		return false // Tüm maçlar oynandı
+		return false // Every match has been played
 	}
 
 	weekMatches := s.matches[s.currentWeek]
-	allPlayed := true
+	var results []MatchResult
+	rng := rand.New(rand.NewSource(deriveSeed(s.seasonSeed(), s.currentWeek+1)))
+
+	engine := s.engine
+	if rated, ok := engine.(RatedEngine); ok {
+		engine = rated.WithRatings(s.ratingsBefore(s.currentWeek + 1))
+	}
 
 	for i := range weekMatches {
 		match := &weekMatches[i]
 		if !match.Played {
-			homeGoals, awayGoals := simulateMatch(match.Home.Strength, match.Away.Strength)
-
-			match.HomeGoals = homeGoals
-			match.AwayGoals = awayGoals
-			match.Played = true
+			result := playMatch(engine, *match, rng)
+			s.assignPlayers(result.Events, rng)
+			result.apply(match)
 
 			updateStandings(s.standings, *match)
-			allPlayed = false
+			results = append(results, result)
+			played := *match
+			s.publish(FeedEvent{Type: FeedMatchPlayed, Match: &played})
 		}
 	}
 
-	s.matches[s.currentWeek] = weekMatches
-	s.currentWeek++
-	return !allPlayed
+	week := s.currentWeek + 1
+	s.currentWeek = s.firstUnplayedWeek()
+	s.recalculateRatings()
+	s.recordEvent(HistoryEvent{Type: EventWeekSimulated, Week: week, Results: results})
+	s.publish(FeedEvent{Type: FeedWeekCompleted, Week: week, Results: results})
+	s.publishStandings()
+	return true
+}
+
+// playMatch plays a single match. Engines that can play a timeline do, and
+// the score is counted from it so the two always agree.
+func playMatch(engine MatchEngine, match models.Match, rng *rand.Rand) MatchResult {
+	result := MatchResult{MatchID: match.ID, Played: true}
+	if timed, ok := engine.(TimelineEngine); ok {
+		result.Events = timed.PlayTimeline(match.Home, match.Away, rng)
+		halfTime := models.Score{}
+		result.HomeGoals, result.AwayGoals, halfTime = timelineScore(match.Home, result.Events)
+		result.HalfTime = &halfTime
+	} else {
+		result.HomeGoals, result.AwayGoals = engine.PlayMatch(match.Home, match.Away, rng)
+	}
+	return result
+}
+
+// firstUnplayedWeek returns the index of the earliest week with an unplayed
+// match, or len(s.matches) when the season is complete. Callers must hold mu.
+func (s *SimulatorImpl) firstUnplayedWeek() int {
+	for weekIdx, weekMatches := range s.matches {
+		for _, match := range weekMatches {
+			if !match.Played {
+				return weekIdx
+			}
+		}
+	}
+	return len(s.matches)
 }
 
 // SimulateAll simulates all remaining weeks until no more matches can be played.
 
 func (s *SimulatorImpl) SimulateAll() {
-	for s.SimulateWeek() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.simulateWeek() {
 		// Simulate all weeks until no more matches can be played
 	}
+	s.persist()
 }
 
-func simulateMatch(homeStrength, awayStrength int) (int, int) {
-	homeGoals := randomGoals(homeStrength)
-	awayGoals := randomGoals(awayStrength)
-
-	return homeGoals, awayGoals
+// SimulateWeekSeeded is SimulateWeek after reseeding the league when seed
+// is not nil. Both happen under one lock, so the returned seeds are the ones
+// the week was played with.
+func (s *SimulatorImpl) SimulateWeekSeeded(seed *int64) (Seeds, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.firstUnplayedWeek() >= len(s.matches) {
+		return s.seeds(), ErrSeasonFinished
+	}
+	if seed != nil {
+		s.reseed(*seed)
+	}
+	s.simulateWeek()
+	s.persist()
+	return s.seeds(), nil
 }
 
-func randomGoals(strength int) int {
-	base := float64(strength) / 5
-	noise := rand.NormFloat64() * 0.5 // normal noise
-	goals := int(base + noise + 1.5)
-	if goals > 5 {
-		return 5
+// SimulateAllSeeded is SimulateAll after reseeding the league when seed is
+// not nil, under one lock. It returns the seeds the season was played with.
+func (s *SimulatorImpl) SimulateAllSeeded(seed *int64) Seeds {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seed != nil {
+		s.reseed(*seed)
 	}
-	return goals
+	for s.simulateWeek() {
+	}
+	s.persist()
+	return s.seeds()
 }
 
 func updateStandings(standings map[int]*models.Standing, match models.Match) {
@@ -173,50 +446,115 @@ func updateStandings(standings map[int]*models.Standing, match models.Match) {
 	homeStanding.GoalDiff = homeStanding.GoalsFor - homeStanding.GoalsAgainst
 	awayStanding.GoalDiff = awayStanding.GoalsFor - awayStanding.GoalsAgainst
 
+	homeDeductions, awayDeductions := cardDeductions(match)
+	homeStanding.FairPlay -= homeDeductions
+	awayStanding.FairPlay -= awayDeductions
+
 	homeStanding.Points = homeStanding.Won*3 + homeStanding.Drawn
 	awayStanding.Points = awayStanding.Won*3 + awayStanding.Drawn
 }
 
-// EditMatchResult allows editing the result of a specific match by ID
-func (s *SimulatorImpl) EditMatchResult(matchID, homeGoals, awayGoals int) error {
-	// Find the match in all weeks
-	for weekIdx := range s.matches {
-		for matchIdx := range s.matches[weekIdx] {
-			match := &s.matches[weekIdx][matchIdx]
-			if match.ID == matchID {
-				// Store old result for standings update
-				oldHomeGoals := match.HomeGoals
-				oldAwayGoals := match.AwayGoals
-
-				// Update match result
-				match.HomeGoals = homeGoals
-				match.AwayGoals = awayGoals
-				match.Played = true
-
-				// If match was already played, reverse old standings first
-				if oldHomeGoals != 0 || oldAwayGoals != 0 {
-					reverseStandings(s.standings, models.Match{
-						ID:        match.ID,
-						Home:      match.Home,
-						Away:      match.Away,
-						HomeGoals: oldHomeGoals,
-						AwayGoals: oldAwayGoals,
-						Week:      match.Week,
-						Played:    true,
-					})
-				}
-
-				// Apply new standings
-				updateStandings(s.standings, *match)
-				return nil
-			}
+// Fair play deductions per card. A standing's FairPlay is the negated sum,
+// so 0 is best and the fair play tiebreaker ranks fewer deductions higher.
+const (
+	yellowCardDeduction = 1
+	redCardDeduction    = 3
+)
+
+// cardDeductions sums the fair play deductions of each side's cards in the
+// match timeline. Matches without a timeline cost nothing.
+func cardDeductions(match models.Match) (home, away int) {
+	for _, event := range match.Events {
+		deduction := 0
+		switch event.Type {
+		case models.EventYellowCard:
+			deduction = yellowCardDeduction
+		case models.EventRedCard:
+			deduction = redCardDeduction
 		}
+		switch event.TeamID {
+		case match.Home.ID:
+			home += deduction
+		case match.Away.ID:
+			away += deduction
+		}
+	}
+	return home, away
+}
+
+// EditMatchResult sets the result of a match, whether or not it has been
+// played. A previous result is reversed out of the table first, so editing a
+// played 0-0 never double counts. Edited future matches keep their result
+// when their week is simulated.
+func (s *SimulatorImpl) EditMatchResult(matchID, homeGoals, awayGoals int) error {
+	if homeGoals < 0 || awayGoals < 0 {
+		return ErrInvalidScore
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	match := s.findMatch(matchID)
+	if match == nil {
+		return fmt.Errorf("%w: %d", ErrMatchNotFound, matchID)
+	}
+
+	// If match was already played, reverse old standings first
+	if match.Played {
+		reverseStandings(s.standings, *match)
+	}
+
+	// An edited score no longer matches the simulated timeline
+	result := MatchResult{MatchID: matchID, HomeGoals: homeGoals, AwayGoals: awayGoals, Played: true}
+	result.apply(match)
+	updateStandings(s.standings, *match)
+
+	s.currentWeek = s.firstUnplayedWeek()
+	s.recalculateRatings()
+	s.recordEvent(HistoryEvent{Type: EventMatchEdited, Results: []MatchResult{result}})
+	s.publishEdit(*match)
+	s.persist()
+	return nil
+}
+
+// ClearMatchResult marks a match unplayed and removes it from the table. The
+// next SimulateWeek replays it as part of the earliest unfinished week.
+func (s *SimulatorImpl) ClearMatchResult(matchID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	match := s.findMatch(matchID)
+	if match == nil {
+		return fmt.Errorf("%w: %d", ErrMatchNotFound, matchID)
+	}
+	if !match.Played {
+		return nil
 	}
-	return fmt.Errorf("match with ID %d not found", matchID)
+
+	reverseStandings(s.standings, *match)
+	MatchResult{MatchID: matchID}.apply(match)
+
+	s.currentWeek = s.firstUnplayedWeek()
+	s.recalculateRatings()
+	s.recordEvent(HistoryEvent{
+		Type:    EventMatchEdited,
+		Results: []MatchResult{{MatchID: matchID, Played: false}},
+	})
+	s.publishEdit(*match)
+	s.persist()
+	return nil
 }
 
 // RecalculateStandings recalculates all standings from scratch based on played matches
 func (s *SimulatorImpl) RecalculateStandings() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recalculateStandings()
+	s.publishStandings()
+	s.persist()
+}
+
+func (s *SimulatorImpl) recalculateStandings() {
 	// Reset all standings
 	for _, standing := range s.standings {
 		standing.Played = 0
@@ -227,6 +565,7 @@ func (s *SimulatorImpl) RecalculateStandings() {
 		standing.GoalsAgainst = 0
 		standing.GoalDiff = 0
 		standing.Points = 0
+		standing.FairPlay = 0
 	}
 
 	// Recalculate from all played matches
@@ -237,6 +576,7 @@ func (s *SimulatorImpl) RecalculateStandings() {
 			}
 		}
 	}
+	s.recalculateRatings()
 }
 
 // reverseStandings reverses the effect of a match on standings
@@ -266,46 +606,73 @@ func reverseStandings(standings map[int]*models.Standing, match models.Match) {
 	homeStanding.GoalDiff = homeStanding.GoalsFor - homeStanding.GoalsAgainst
 	awayStanding.GoalDiff = awayStanding.GoalsFor - awayStanding.GoalsAgainst
 
+	homeDeductions, awayDeductions := cardDeductions(match)
+	homeStanding.FairPlay += homeDeductions
+	awayStanding.FairPlay += awayDeductions
+
 	homeStanding.Points = homeStanding.Won*3 + homeStanding.Drawn
 	awayStanding.Points = awayStanding.Won*3 + awayStanding.Drawn
 }
 
-// GetMatchByID finds and returns a match by its ID
+// GetMatchByID finds and returns a copy of a match by its ID
 func (s *SimulatorImpl) GetMatchByID(matchID int) (*models.Match, error) {
-	for weekIdx := range s.matches {
-		for matchIdx := range s.matches[weekIdx] {
-			if s.matches[weekIdx][matchIdx].ID == matchID {
-				return &s.matches[weekIdx][matchIdx], nil
-			}
-		}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if match := s.findMatch(matchID); match != nil {
+		copied := *match
+		return &copied, nil
 	}
-	return nil, fmt.Errorf("match with ID %d not found", matchID)
+	return nil, fmt.Errorf("%w: %d", ErrMatchNotFound, matchID)
 }
 
 // GetStandings returns the current standings of the league.
 func (s *SimulatorImpl) GetStandings() []models.Standing {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedStandings()
+}
+
+// sortedStandings ranks the table with the league's tiebreaker chain.
+// Callers must hold mu.
+func (s *SimulatorImpl) sortedStandings() []models.Standing {
 	var standings []models.Standing
 	for _, standing := range s.standings {
 		standings = append(standings, *standing)
 	}
 
-	// Sort standings by points, then goal difference, then goals for
-	sort.Slice(standings, func(i, j int) bool {
-		if standings[i].Points != standings[j].Points {
-			return standings[i].Points > standings[j].Points
+	ctx := rankContext{seed: s.seed}
+	if needsMatches(s.tiebreakers) {
+		for _, weekMatches := range s.matches {
+			ctx.matches = append(ctx.matches, weekMatches...)
 		}
-		if standings[i].GoalDiff != standings[j].GoalDiff {
-			return standings[i].GoalDiff > standings[j].GoalDiff
-		}
-		return standings[i].GoalsFor > standings[j].GoalsFor
-	})
-
+	}
+	rankStandings(standings, s.tiebreakers, ctx)
 	return standings
 }
+
+// Matches returns a copy of the fixture list grouped by week.
 func (s *SimulatorImpl) Matches() [][]models.Match {
-	return s.matches
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.matchesCopy()
+}
+
+func (s *SimulatorImpl) matchesCopy() [][]models.Match {
+	copied := make([][]models.Match, len(s.matches))
+	for i, weekMatches := range s.matches {
+		copied[i] = append([]models.Match(nil), weekMatches...)
+	}
+	return copied
 }
+
 func (s *SimulatorImpl) StandingsCopy() map[int]*models.Standing {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.standingsCopy()
+}
+
+func (s *SimulatorImpl) standingsCopy() map[int]*models.Standing {
 	copied := make(map[int]*models.Standing)
 	for id, st := range s.standings {
 		copy := *st
@@ -316,15 +683,11 @@ func (s *SimulatorImpl) StandingsCopy() map[int]*models.Standing {
 
 // Reset resets the league simulator to the initial state
 func (s *SimulatorImpl) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	// Reset all matches
-	for weekIdx := range s.matches {
-		for matchIdx := range s.matches[weekIdx] {
-			match := &s.matches[weekIdx][matchIdx]
-			match.HomeGoals = 0
-			match.AwayGoals = 0
-			match.Played = false
-		}
-	}
+	clearResults(s.matches)
 	// Reset standings
 	for _, standing := range s.standings {
 		standing.Played = 0
@@ -335,6 +698,138 @@ func (s *SimulatorImpl) Reset() {
 		standing.GoalsAgainst = 0
 		standing.GoalDiff = 0
 		standing.Points = 0
+		standing.FairPlay = 0
 	}
 	s.currentWeek = 0
+	s.recalculateRatings()
+	s.recordEvent(HistoryEvent{Type: EventReset})
+	s.publishStandings()
+	s.persist()
+}
+
+// Seeds describes the seeds a league plays with. League is the league seed,
+// the one a simulate request sets; Season is the seed the current season's
+// weeks are derived from, which moves on by one each season. Sending League
+// back after a reset replays the season.
+type Seeds struct {
+	League int64
+	Season int64
+}
+
+// Seeds returns the league and season seeds.
+func (s *SimulatorImpl) Seeds() Seeds {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.seeds()
+}
+
+// seeds is Seeds for callers that hold mu.
+func (s *SimulatorImpl) seeds() Seeds {
+	return Seeds{League: s.seed, Season: s.seasonSeed()}
+}
+
+// ID returns the league's registry ID.
+func (s *SimulatorImpl) ID() int {
+	return s.id
+}
+
+// Name returns the league's display name.
+func (s *SimulatorImpl) Name() string {
+	return s.name
+}
+
+// Tiebreakers returns the league's ranking chain. Like the engine it is fixed
+// at construction time.
+func (s *SimulatorImpl) Tiebreakers() []Tiebreaker {
+	return s.tiebreakers
+}
+
+// Engine returns the match engine used by the league. The engine is fixed at
+// construction time, so no locking is needed.
+func (s *SimulatorImpl) Engine() MatchEngine {
+	return s.engine
+}
+
+// Reseed sets the seed the following weeks are drawn from.
+func (s *SimulatorImpl) Reseed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reseed(seed)
+	s.persist()
+}
+
+// reseed sets the seed the following weeks are drawn from. Callers must
+// hold mu.
+func (s *SimulatorImpl) reseed(seed int64) {
+	s.seed = seed
+}
+
+// deriveSeed mixes n into seed with the SplitMix64 finaliser. Every week of
+// a season and every round of a cup draws from its own derived seed, so a
+// result depends only on the seed and the state it is played from, not on
+// how many draws were taken before a restart.
+func deriveSeed(seed int64, n int) int64 {
+	z := uint64(seed) + uint64(n)*0x9e3779b97f4a7c15
+	z = (z ^ z>>30) * 0xbf58476d1ce4e5b9
+	z = (z ^ z>>27) * 0x94d049bb133111eb
+	return int64(z ^ z>>31)
+}
+
+// Snapshot returns matches, standings, week and seed copied under one lock.
+func (s *SimulatorImpl) Snapshot() LeagueSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return LeagueSnapshot{
+		Teams:       append([]models.Team(nil), s.teams...),
+		Matches:     s.matchesCopy(),
+		Standings:   s.standingsCopy(),
+		Table:       s.sortedStandings(),
+		CurrentWeek: s.currentWeek,
+		Seed:        s.seed,
+		Season:      s.season,
+		Ratings:     s.currentRatings(),
+	}
+}
+
+// state copies the league into a LeagueState. Callers must hold mu.
+func (s *SimulatorImpl) state() LeagueState {
+	return LeagueState{
+		ID:           s.id,
+		Name:         s.name,
+		Teams:        append([]models.Team(nil), s.teams...),
+		Players:      append([]models.Player(nil), s.players...),
+		Matches:      s.matchesCopy(),
+		Standings:    s.standingsCopy(),
+		CurrentWeek:  s.currentWeek,
+		Seed:         s.seed,
+		Tiebreakers:  TiebreakerNames(s.tiebreakers),
+		Engine:       EngineName(s.engine),
+		History:      append([]HistoryEvent(nil), s.history...),
+		Cursor:       s.cursor,
+		Season:       s.season,
+		Seasons:      append([]SeasonRecord(nil), s.seasons...),
+		Remainders:   copyRemainders(s.remainders),
+		Playoff:      s.playoffState(),
+		NextPlayerID: s.nextPlayerID,
+	}
+}
+
+// persist writes the league through to the repository, if one is configured.
+// Callers must hold mu.
+func (s *SimulatorImpl) persist() {
+	if s.repo == nil {
+		return
+	}
+	if err := s.repo.SaveLeague(s.state()); err != nil {
+		log.Printf("failed to persist league %d: %v", s.id, err)
+	}
+}
+
+// detach stops the league writing through to its repository and feed, so requests
+// still holding a deleted league cannot resurrect it.
+func (s *SimulatorImpl) detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.repo = nil
+	s.feed = nil
 }
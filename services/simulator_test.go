@@ -0,0 +1,306 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"league-simulator/models"
+)
+
+func testTeams(n int) []models.Team {
+	teams := make([]models.Team, n)
+	for i := range teams {
+		teams[i] = models.Team{ID: i + 1, Name: fmt.Sprintf("Team %d", i+1), Strength: 4 + i%5}
+	}
+	return teams
+}
+
+// checkSnapshot verifies that the table agrees with the played matches.
+func checkSnapshot(snapshot LeagueSnapshot) error {
+	played, draws, goals := 0, 0, 0
+	for _, weekMatches := range snapshot.Matches {
+		for _, match := range weekMatches {
+			if !match.Played {
+				continue
+			}
+			played++
+			goals += match.HomeGoals + match.AwayGoals
+			if match.HomeGoals == match.AwayGoals {
+				draws++
+			}
+		}
+	}
+
+	var sumPlayed, sumWon, sumDrawn, sumLost, sumFor, sumAgainst int
+	for _, standing := range snapshot.Table {
+		sumPlayed += standing.Played
+		sumWon += standing.Won
+		sumDrawn += standing.Drawn
+		sumLost += standing.Lost
+		sumFor += standing.GoalsFor
+		sumAgainst += standing.GoalsAgainst
+		if standing.Points != 3*standing.Won+standing.Drawn {
+			return fmt.Errorf("%s has %d points from %d wins and %d draws", standing.Team.Name, standing.Points, standing.Won, standing.Drawn)
+		}
+		if standing.Played != standing.Won+standing.Drawn+standing.Lost {
+			return fmt.Errorf("%s played %d but has %d results", standing.Team.Name, standing.Played, standing.Won+standing.Drawn+standing.Lost)
+		}
+	}
+	switch {
+	case sumPlayed != 2*played:
+		return fmt.Errorf("table counts %d appearances for %d played matches", sumPlayed, played)
+	case sumWon != sumLost || sumWon != played-draws:
+		return fmt.Errorf("table has %d wins and %d losses for %d decisive matches", sumWon, sumLost, played-draws)
+	case sumDrawn != 2*draws:
+		return fmt.Errorf("table has %d draws for %d drawn matches", sumDrawn, draws)
+	case sumFor != goals || sumAgainst != goals:
+		return fmt.Errorf("table has %d goals for and %d against, matches have %d", sumFor, sumAgainst, goals)
+	}
+	return nil
+}
+
+// checkHistory verifies that no week or match was simulated twice since the
+// last reset.
+func checkHistory(events []HistoryEvent) error {
+	weeks := make(map[int]bool)
+	matches := make(map[int]bool)
+	for _, event := range events {
+		switch event.Type {
+		case EventReset:
+			weeks = make(map[int]bool)
+			matches = make(map[int]bool)
+		case EventWeekSimulated:
+			if weeks[event.Week] {
+				return fmt.Errorf("week %d simulated twice (event %d)", event.Week, event.Seq)
+			}
+			weeks[event.Week] = true
+			for _, result := range event.Results {
+				if matches[result.MatchID] {
+					return fmt.Errorf("match %d simulated twice (event %d)", result.MatchID, event.Seq)
+				}
+				matches[result.MatchID] = true
+			}
+		}
+	}
+	return nil
+}
+
+func TestConcurrentLeagueOperations(t *testing.T) {
+	s := newSimulator(testTeams(6), WithSeed(1))
+	matchCount := 0
+	for _, weekMatches := range s.Matches() {
+		matchCount += len(weekMatches)
+	}
+
+	var wg sync.WaitGroup
+	run := func(n int, op func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				op(i)
+			}
+		}()
+	}
+
+	run(40, func(int) {
+		if err := s.SimulateWeek(); err != nil && !errors.Is(err, ErrSeasonFinished) {
+			t.Errorf("SimulateWeek: %v", err)
+		}
+	})
+	run(10, func(int) { s.SimulateAll() })
+	run(5, func(int) { s.Reset() })
+	run(40, func(i int) {
+		if err := s.EditMatchResult(i%matchCount+1, i%4, i%3); err != nil {
+			t.Errorf("EditMatchResult: %v", err)
+		}
+	})
+	for reader := 0; reader < 4; reader++ {
+		run(50, func(int) {
+			if err := checkSnapshot(s.Snapshot()); err != nil {
+				t.Errorf("inconsistent snapshot: %v", err)
+			}
+			s.GetStandings()
+			s.Matches()
+			s.History()
+		})
+	}
+	wg.Wait()
+
+	if err := checkSnapshot(s.Snapshot()); err != nil {
+		t.Errorf("final snapshot: %v", err)
+	}
+	events, _ := s.History()
+	if err := checkHistory(events); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSnapshotIsACopy(t *testing.T) {
+	s := newSimulator(testTeams(4), WithSeed(3))
+	snapshot := s.Snapshot()
+	snapshot.Matches[0][0].Played = true
+	snapshot.Matches[0][0].HomeGoals = 9
+
+	if match, _ := s.GetMatchByID(snapshot.Matches[0][0].ID); match.Played {
+		t.Fatal("changing a snapshot changed the league")
+	}
+}
+
+func TestRestoredLeagueContinuesTheSameSeason(t *testing.T) {
+	uninterrupted := newSimulator(testTeams(6), WithSeed(7))
+	uninterrupted.SimulateAll()
+
+	restarted := newSimulator(testTeams(6), WithSeed(7))
+	for week := 0; week < 4; week++ {
+		restarted.SimulateWeek()
+		restarted = restoreSimulator(restarted.state())
+	}
+	restarted.SimulateAll()
+
+	want, got := uninterrupted.Matches(), restarted.Matches()
+	for w := range want {
+		for m := range want[w] {
+			if want[w][m].HomeGoals != got[w][m].HomeGoals || want[w][m].AwayGoals != got[w][m].AwayGoals {
+				t.Fatalf("week %d match %d: %d-%d after restarts, %d-%d without", w+1, m+1,
+					got[w][m].HomeGoals, got[w][m].AwayGoals, want[w][m].HomeGoals, want[w][m].AwayGoals)
+			}
+		}
+	}
+}
+
+func TestFairPlayCountsCards(t *testing.T) {
+	s := newSimulator(testTeams(6), WithSeed(11))
+	s.SimulateAll()
+
+	want := func() map[int]int {
+		deductions := make(map[int]int)
+		for _, weekMatches := range s.Matches() {
+			for _, match := range weekMatches {
+				home, away := cardDeductions(match)
+				deductions[match.Home.ID] -= home
+				deductions[match.Away.ID] -= away
+			}
+		}
+		return deductions
+	}
+	check := func(when string) {
+		t.Helper()
+		deductions := want()
+		for _, standing := range s.GetStandings() {
+			if standing.FairPlay != deductions[standing.Team.ID] {
+				t.Errorf("%s: %s has fair play %d, cards give %d", when, standing.Team.Name, standing.FairPlay, deductions[standing.Team.ID])
+			}
+		}
+	}
+
+	check("after the season")
+	total := 0
+	for _, deduction := range want() {
+		total += deduction
+	}
+	if total == 0 {
+		t.Fatal("a whole season produced no cards")
+	}
+
+	// An edit drops the match timeline and its cards with it.
+	s.EditMatchResult(1, 2, 2)
+	check("after an edit")
+	s.RecalculateStandings()
+	check("after recalculating")
+}
+
+func TestConcurrentTeamUpdatesKeepEachField(t *testing.T) {
+	s := newSimulator(testTeams(4), WithSeed(5))
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 50; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			strength := 10 + i
+			if _, err := s.UpdateTeam(1, TeamUpdate{Strength: &strength}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			name := "Renamed"
+			if _, err := s.UpdateTeam(1, TeamUpdate{Name: &name}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	team := s.Teams()[0]
+	if team.Name != "Renamed" || team.Strength == testTeams(4)[0].Strength {
+		t.Errorf("an update was lost: %+v", team)
+	}
+	tooStrong := MaxTeamRating + 1
+	if _, err := s.UpdateTeam(1, TeamUpdate{Strength: &tooStrong}); !errors.Is(err, ErrInvalidTeams) {
+		t.Errorf("strength %d: got %v, want ErrInvalidTeams", tooStrong, err)
+	}
+}
+
+func TestSimulatedWeekIgnoresLaterResults(t *testing.T) {
+	s := newSimulator(testTeams(6), WithSeed(9), WithMatchEngine(NewEloEngine()))
+	initial := s.elo.InitialRatings(s.teams)
+
+	// A thrashing entered for the last week must not shape the first.
+	weeks := s.Matches()
+	last := weeks[len(weeks)-1][0]
+	s.EditMatchResult(last.ID, 9, 0)
+
+	for id, rating := range s.ratingsBefore(1) {
+		if rating != initial[id] {
+			t.Errorf("team %d goes into week 1 rated %.1f, want %.1f", id, rating, initial[id])
+		}
+	}
+	if current := s.currentRatings(); current[last.Home.ID] <= initial[last.Home.ID] {
+		t.Errorf("the edit did not move the current ratings")
+	}
+}
+
+func TestRatingHistoryRatesEveryListedTeam(t *testing.T) {
+	s := newSimulator(testTeams(4), WithSeed(3))
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			s.AddTeam(models.Team{Name: fmt.Sprintf("Added %d", i), Strength: 5})
+		}
+	}()
+	for i := 0; i < 20; i++ {
+		history := s.RatingHistory()
+		for _, team := range history.Teams {
+			for week, ratings := range history.Weeks {
+				if _, ok := ratings[team.ID]; !ok {
+					t.Fatalf("team %d is listed without a week %d rating", team.ID, week)
+				}
+			}
+		}
+	}
+	wg.Wait()
+}
+
+func TestPlayerIDsAreNotReused(t *testing.T) {
+	s := newSimulator(testTeams(4), WithSeed(2))
+	first, _ := s.AddPlayer(models.Player{TeamID: 1, Name: "First", Position: "FW"})
+	second, _ := s.AddPlayer(models.Player{TeamID: 1, Name: "Second", Position: "FW"})
+	if err := s.RemovePlayer(second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	restored := restoreSimulator(s.state())
+	third, err := restored.AddPlayer(models.Player{TeamID: 1, Name: "Third", Position: "FW"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.ID == first.ID || third.ID == second.ID {
+		t.Errorf("new player got ID %d, already used by %d or %d", third.ID, first.ID, second.ID)
+	}
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrTeamNotFound is returned when no team in the league has the requested ID.
+	ErrTeamNotFound = errors.New("team not found")
+	// ErrSeasonStarted is returned for structural changes once a match has been played.
+	ErrSeasonStarted = errors.New("league has started; teams cannot be added or removed until it is reset")
+)
+
+// MaxTeamRating is the highest strength, attack or defence a team can have.
+const MaxTeamRating = 100
+
+// TeamUpdate lists the fields UpdateTeam changes. Nil fields keep their
+// current value.
+type TeamUpdate struct {
+	Name     *string
+	Strength *int
+	Attack   *int
+	Defence  *int
+}
+
+// Teams returns the league's teams in fixture order.
+func (s *SimulatorImpl) Teams() []models.Team {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Team(nil), s.teams...)
+}
+
+// AddTeam adds a team to a league that has not started and regenerates the
+// fixtures. A zero ID is replaced by the next free ID.
+func (s *SimulatorImpl) AddTeam(team models.Team) (models.Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.hasStarted() {
+		return models.Team{}, ErrSeasonStarted
+	}
+	if err := validateTeam(&team); err != nil {
+		return models.Team{}, err
+	}
+	if team.ID == 0 {
+		for _, t := range s.teams {
+			if t.ID > team.ID {
+				team.ID = t.ID
+			}
+		}
+		team.ID++
+	}
+	for _, t := range s.teams {
+		if t.ID == team.ID {
+			return models.Team{}, fmt.Errorf("%w: duplicate team ID %d", ErrInvalidTeams, team.ID)
+		}
+	}
+
+	s.teams = append(s.teams, team)
+	s.regenerateFixtures()
+	s.publishStandings()
+	s.persist()
+	return team, nil
+}
+
+// UpdateTeam changes the name and ratings of an existing team. The update is
+// merged into the team under the same lock that stores it, so concurrent
+// updates of different fields do not undo each other. This is not a
+// structural change, so it is allowed at any point in the season; played
+// results are kept and the new ratings apply to future matches.
+func (s *SimulatorImpl) UpdateTeam(teamID int, update TeamUpdate) (models.Team, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.teamIndex(teamID)
+	if idx < 0 {
+		return models.Team{}, ErrTeamNotFound
+	}
+	team := s.teams[idx]
+	if update.Name != nil {
+		team.Name = *update.Name
+	}
+	if update.Strength != nil {
+		team.Strength = *update.Strength
+	}
+	if update.Attack != nil {
+		team.Attack = *update.Attack
+	}
+	if update.Defence != nil {
+		team.Defence = *update.Defence
+	}
+	if err := validateTeam(&team); err != nil {
+		return models.Team{}, err
+	}
+
+	if update.Strength != nil || update.Attack != nil || update.Defence != nil {
+		// Hand-set ratings start afresh rather than carry a remainder
+		delete(s.remainders, team.ID)
+	}
+	s.teams[idx] = team
+	for weekIdx := range s.matches {
+		for matchIdx := range s.matches[weekIdx] {
+			match := &s.matches[weekIdx][matchIdx]
+			if match.Home.ID == team.ID {
+				match.Home = team
+			}
+			if match.Away.ID == team.ID {
+				match.Away = team
+			}
+		}
+	}
+	s.standings[team.ID].Team = team
+	// Strength seeds the initial Elo rating
+	s.recalculateRatings()
+	s.publishStandings()
+	s.persist()
+	return team, nil
+}
+
+// RemoveTeam removes a team and its squad from a league that has not started
+// and regenerates the fixtures.
+func (s *SimulatorImpl) RemoveTeam(teamID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := s.teamIndex(teamID)
+	if idx < 0 {
+		return ErrTeamNotFound
+	}
+	if s.hasStarted() {
+		return ErrSeasonStarted
+	}
+	if len(s.teams) <= 2 {
+		return fmt.Errorf("%w: a league needs at least 2 teams", ErrInvalidTeams)
+	}
+
+	s.teams = append(s.teams[:idx:idx], s.teams[idx+1:]...)
+	squad := s.players[:0:0]
+	for _, player := range s.players {
+		if player.TeamID != teamID {
+			squad = append(squad, player)
+		}
+	}
+	s.players = squad
+	s.regenerateFixtures()
+	s.publishStandings()
+	s.persist()
+	return nil
+}
+
+// hasStarted reports whether any match has been played. Callers must hold mu.
+func (s *SimulatorImpl) hasStarted() bool {
+	for _, weekMatches := range s.matches {
+		for _, match := range weekMatches {
+			if match.Played {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// teamIndex returns the position of a team in s.teams, or -1. Callers must hold mu.
+func (s *SimulatorImpl) teamIndex(teamID int) int {
+	for i, team := range s.teams {
+		if team.ID == teamID {
+			return i
+		}
+	}
+	return -1
+}
+
+// regenerateFixtures rebuilds the schedule and an empty table for the
+// current teams. Callers must hold mu.
+func (s *SimulatorImpl) regenerateFixtures() {
+	s.matches = generateFixtures(s.teams)
+	s.standings = make(map[int]*models.Standing)
+	for _, team := range s.teams {
+		s.standings[team.ID] = &models.Standing{Team: team}
+	}
+	s.currentWeek = 0
+	s.recalculateRatings()
+	// Recorded results refer to the old fixtures
+	s.clearHistory()
+	s.playoff = nil
+}
+
+// validateTeam trims the team's name and checks its ratings.
+func validateTeam(team *models.Team) error {
+	team.Name = strings.TrimSpace(team.Name)
+	if team.Name == "" {
+		return fmt.Errorf("%w: team name is required", ErrInvalidTeams)
+	}
+	if team.ID < 0 || team.Strength < 0 || team.Attack < 0 || team.Defence < 0 {
+		return fmt.Errorf("%w: %s has a negative ID or rating", ErrInvalidTeams, team.Name)
+	}
+	if team.Strength > MaxTeamRating || team.Attack > MaxTeamRating || team.Defence > MaxTeamRating {
+		return fmt.Errorf("%w: %s has a rating above %d", ErrInvalidTeams, team.Name, MaxTeamRating)
+	}
+	return nil
+}
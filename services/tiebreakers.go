@@ -0,0 +1,234 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"league-simulator/models"
+)
+
+// ErrUnknownTiebreaker is returned for a rule or preset name that does not exist.
+var ErrUnknownTiebreaker = errors.New("unknown tiebreaker")
+
+// Tiebreaker is one rule in a league's ranking chain. Rules are applied in
+// order; each one only reorders teams that every earlier rule left level.
+type Tiebreaker struct {
+	Name string
+	// usesMatches marks rules that need match results rather than the table.
+	usesMatches bool
+	// key scores every team in tied; a higher score ranks first. tied holds
+	// only the teams still level, which head-to-head rules use as their
+	// mini-league.
+	key func(ctx rankContext, tied []models.Standing) map[int]float64
+}
+
+// rankContext carries what rules need beyond the table itself.
+type rankContext struct {
+	matches []models.Match
+	seed    int64
+}
+
+// Rule names accepted by ParseTiebreakers.
+const (
+	TiebreakPoints         = "points"
+	TiebreakGoalDifference = "goal_difference"
+	TiebreakGoalsFor       = "goals_for"
+	TiebreakH2HPoints      = "head_to_head_points"
+	TiebreakH2HGoalDiff    = "head_to_head_goal_difference"
+	TiebreakH2HGoalsFor    = "head_to_head_goals_for"
+	TiebreakH2HAwayGoals   = "head_to_head_away_goals"
+	TiebreakWins           = "wins"
+	TiebreakAwayGoals      = "away_goals"
+	TiebreakFairPlay       = "fair_play"
+	TiebreakDrawingOfLots  = "lots"
+)
+
+var tiebreakers = map[string]Tiebreaker{
+	TiebreakPoints:         {Name: TiebreakPoints, key: standingKey(func(st models.Standing) int { return st.Points })},
+	TiebreakGoalDifference: {Name: TiebreakGoalDifference, key: standingKey(func(st models.Standing) int { return st.GoalDiff })},
+	TiebreakGoalsFor:       {Name: TiebreakGoalsFor, key: standingKey(func(st models.Standing) int { return st.GoalsFor })},
+	TiebreakWins:           {Name: TiebreakWins, key: standingKey(func(st models.Standing) int { return st.Won })},
+	TiebreakFairPlay:       {Name: TiebreakFairPlay, key: standingKey(func(st models.Standing) int { return st.FairPlay })},
+	TiebreakH2HPoints:      {Name: TiebreakH2HPoints, usesMatches: true, key: headToHeadKey(func(r h2hRecord) int { return r.points })},
+	TiebreakH2HGoalDiff:    {Name: TiebreakH2HGoalDiff, usesMatches: true, key: headToHeadKey(func(r h2hRecord) int { return r.goalsFor - r.goalsAgainst })},
+	TiebreakH2HGoalsFor:    {Name: TiebreakH2HGoalsFor, usesMatches: true, key: headToHeadKey(func(r h2hRecord) int { return r.goalsFor })},
+	TiebreakH2HAwayGoals:   {Name: TiebreakH2HAwayGoals, usesMatches: true, key: headToHeadKey(func(r h2hRecord) int { return r.awayGoals })},
+	TiebreakAwayGoals:      {Name: TiebreakAwayGoals, usesMatches: true, key: awayGoalsKey},
+	TiebreakDrawingOfLots:  {Name: TiebreakDrawingOfLots, key: lotsKey},
+}
+
+// TiebreakerPresets are the chains used by well-known competitions.
+var TiebreakerPresets = map[string][]string{
+	"default":        {TiebreakPoints, TiebreakGoalDifference, TiebreakGoalsFor},
+	"premier_league": {TiebreakPoints, TiebreakGoalDifference, TiebreakGoalsFor, TiebreakH2HPoints, TiebreakH2HAwayGoals, TiebreakDrawingOfLots},
+	"la_liga":        {TiebreakPoints, TiebreakH2HPoints, TiebreakH2HGoalDiff, TiebreakGoalDifference, TiebreakGoalsFor, TiebreakFairPlay, TiebreakDrawingOfLots},
+	"serie_a":        {TiebreakPoints, TiebreakH2HPoints, TiebreakH2HGoalDiff, TiebreakGoalDifference, TiebreakGoalsFor, TiebreakDrawingOfLots},
+	"uefa": {TiebreakPoints, TiebreakH2HPoints, TiebreakH2HGoalDiff, TiebreakH2HGoalsFor, TiebreakH2HAwayGoals,
+		TiebreakGoalDifference, TiebreakGoalsFor, TiebreakAwayGoals, TiebreakWins, TiebreakFairPlay, TiebreakDrawingOfLots},
+}
+
+// DefaultTiebreakers ranks by points, then goal difference, then goals for.
+var DefaultTiebreakers = mustParseTiebreakers(TiebreakerPresets["default"])
+
+// ParseTiebreakers resolves rule names into a chain. A single preset name
+// such as "la_liga" expands to that preset's chain.
+func ParseTiebreakers(names []string) ([]Tiebreaker, error) {
+	if len(names) == 1 {
+		if preset, ok := TiebreakerPresets[names[0]]; ok {
+			names = preset
+		}
+	}
+	rules := make([]Tiebreaker, 0, len(names))
+	for _, name := range names {
+		rule, ok := tiebreakers[name]
+		if !ok {
+			return nil, fmt.Errorf("%w %q", ErrUnknownTiebreaker, name)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func mustParseTiebreakers(names []string) []Tiebreaker {
+	rules, err := ParseTiebreakers(names)
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}
+
+// TiebreakerNames returns the names of a chain, e.g. for persistence.
+func TiebreakerNames(rules []Tiebreaker) []string {
+	names := make([]string, len(rules))
+	for i, rule := range rules {
+		names[i] = rule.Name
+	}
+	return names
+}
+
+// needsMatches reports whether any rule in the chain reads match results.
+func needsMatches(rules []Tiebreaker) bool {
+	for _, rule := range rules {
+		if rule.usesMatches {
+			return true
+		}
+	}
+	return false
+}
+
+// rankStandings orders table by the tiebreaker chain and records on each
+// entry the rule that separated it from the team directly above. Teams the
+// chain cannot separate keep ascending ID order and an empty Tiebreaker.
+func rankStandings(table []models.Standing, rules []Tiebreaker, ctx rankContext) {
+	sort.Slice(table, func(i, j int) bool {
+		return table[i].Team.ID < table[j].Team.ID
+	})
+	labels := make([]string, len(table))
+	resolveTies(table, labels, rules, ctx)
+	for i := range table {
+		table[i].Tiebreaker = labels[i]
+	}
+}
+
+// resolveTies applies rules[0] to group, then recurses into every run of
+// teams it leaves level. labels is aligned with group by position.
+func resolveTies(group []models.Standing, labels []string, rules []Tiebreaker, ctx rankContext) {
+	if len(group) < 2 || len(rules) == 0 {
+		return
+	}
+	rule := rules[0]
+	keys := rule.key(ctx, group)
+	sort.SliceStable(group, func(i, j int) bool {
+		return keys[group[i].Team.ID] > keys[group[j].Team.ID]
+	})
+
+	start := 0
+	for i := 1; i <= len(group); i++ {
+		if i < len(group) && keys[group[i].Team.ID] == keys[group[start].Team.ID] {
+			continue
+		}
+		if i < len(group) {
+			labels[i] = rule.Name
+		}
+		resolveTies(group[start:i], labels[start:i], rules[1:], ctx)
+		start = i
+	}
+}
+
+func standingKey(value func(models.Standing) int) func(rankContext, []models.Standing) map[int]float64 {
+	return func(_ rankContext, tied []models.Standing) map[int]float64 {
+		keys := make(map[int]float64, len(tied))
+		for _, st := range tied {
+			keys[st.Team.ID] = float64(value(st))
+		}
+		return keys
+	}
+}
+
+// h2hRecord is a team's record in the mini-league between tied teams.
+type h2hRecord struct {
+	points       int
+	goalsFor     int
+	goalsAgainst int
+	awayGoals    int
+}
+
+func headToHeadKey(value func(h2hRecord) int) func(rankContext, []models.Standing) map[int]float64 {
+	return func(ctx rankContext, tied []models.Standing) map[int]float64 {
+		records := make(map[int]*h2hRecord, len(tied))
+		for _, st := range tied {
+			records[st.Team.ID] = &h2hRecord{}
+		}
+		for _, match := range ctx.matches {
+			home, away := records[match.Home.ID], records[match.Away.ID]
+			if !match.Played || home == nil || away == nil {
+				continue
+			}
+			home.goalsFor += match.HomeGoals
+			home.goalsAgainst += match.AwayGoals
+			away.goalsFor += match.AwayGoals
+			away.goalsAgainst += match.HomeGoals
+			away.awayGoals += match.AwayGoals
+			switch {
+			case match.HomeGoals > match.AwayGoals:
+				home.points += 3
+			case match.HomeGoals < match.AwayGoals:
+				away.points += 3
+			default:
+				home.points++
+				away.points++
+			}
+		}
+
+		keys := make(map[int]float64, len(tied))
+		for id, record := range records {
+			keys[id] = float64(value(*record))
+		}
+		return keys
+	}
+}
+
+func awayGoalsKey(ctx rankContext, tied []models.Standing) map[int]float64 {
+	keys := make(map[int]float64, len(tied))
+	for _, st := range tied {
+		keys[st.Team.ID] = 0
+	}
+	for _, match := range ctx.matches {
+		if _, ok := keys[match.Away.ID]; ok && match.Played {
+			keys[match.Away.ID] += float64(match.AwayGoals)
+		}
+	}
+	return keys
+}
+
+// lotsKey draws lots reproducibly: the same league seed always produces the
+// same draw.
+func lotsKey(ctx rankContext, tied []models.Standing) map[int]float64 {
+	keys := make(map[int]float64, len(tied))
+	for _, st := range tied {
+		keys[st.Team.ID] = rand.New(rand.NewSource(ctx.seed ^ int64(st.Team.ID)*2654435761)).Float64()
+	}
+	return keys
+}
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"math/rand"
+	"sort"
+
+	"league-simulator/models"
+)
+
+// TimelineEngine is a MatchEngine that can also play a match minute by
+// minute. The simulator prefers PlayTimeline and takes the final score from
+// the goals in the timeline.
+type TimelineEngine interface {
+	MatchEngine
+	PlayTimeline(home, away models.Team, rng *rand.Rand) []models.MatchEvent
+}
+
+const (
+	// yellowCardsPerMatch is the expected number of yellow cards per side.
+	yellowCardsPerMatch = 1.8
+	// redCardsPerMatch is the expected number of red cards per side.
+	redCardsPerMatch = 0.08
+	// sentOffFactor scales the scoring rate of a side each time it has a
+	// player sent off; the opponent's rate is divided by it.
+	sentOffFactor = 0.75
+	// minSubstitutions and maxSubstitutions bound the changes a side makes.
+	minSubstitutions = 3
+	maxSubstitutions = 5
+)
+
+// timelineSide is one team's state while a timeline is played.
+type timelineSide struct {
+	team     models.Team
+	rate     float64 // Goals per minute
+	subs     []int   // Minutes of planned substitutions, in order
+	opponent *timelineSide
+}
+
+// playTimeline plays a match minute by minute from model's expected goals.
+// Each minute, stoppage time included, either side may score, be booked or
+// have a player sent off, which weakens it for the rest of the match.
+// Substitutions are planned up front for the second half.
+func playTimeline(model GoalModel, home, away models.Team, rng *rand.Rand) []models.MatchEvent {
+	homeXG, awayXG := model.ExpectedGoals(home, away)
+	firstAdded := 1 + rng.Intn(4)
+	secondAdded := 2 + rng.Intn(5)
+	minutes := float64(90 + firstAdded + secondAdded)
+
+	homeSide := &timelineSide{team: home, rate: homeXG / minutes}
+	awaySide := &timelineSide{team: away, rate: awayXG / minutes}
+	homeSide.opponent, awaySide.opponent = awaySide, homeSide
+	sides := []*timelineSide{homeSide, awaySide}
+	for _, side := range sides {
+		side.subs = planSubstitutions(rng)
+	}
+
+	var events []models.MatchEvent
+	play := func(minute, addedTime int) {
+		clock := minute + addedTime
+		for _, side := range sides {
+			event := models.MatchEvent{Minute: minute, AddedTime: addedTime, TeamID: side.team.ID}
+			if rng.Float64() < side.rate {
+				event.Type = models.EventGoal
+				events = append(events, event)
+			}
+			switch r := rng.Float64(); {
+			case r < redCardsPerMatch/minutes:
+				event.Type = models.EventRedCard
+				events = append(events, event)
+				side.rate *= sentOffFactor
+				side.opponent.rate /= sentOffFactor
+			case r < (redCardsPerMatch+yellowCardsPerMatch)/minutes:
+				event.Type = models.EventYellowCard
+				events = append(events, event)
+			}
+			for minute > 45 && len(side.subs) > 0 && side.subs[0] == clock {
+				event.Type = models.EventSubstitution
+				events = append(events, event)
+				side.subs = side.subs[1:]
+			}
+		}
+	}
+	for m := 1; m <= 45+firstAdded; m++ {
+		play(min(m, 45), max(m-45, 0))
+	}
+	for m := 46; m <= 90+secondAdded; m++ {
+		play(min(m, 90), max(m-90, 0))
+	}
+	return events
+}
+
+// planSubstitutions returns the minutes a side makes its changes, some at
+// half-time and the rest in the second half.
+func planSubstitutions(rng *rand.Rand) []int {
+	count := minSubstitutions + rng.Intn(maxSubstitutions-minSubstitutions+1)
+	subs := make([]int, count)
+	for i := range subs {
+		if rng.Float64() < 0.2 {
+			subs[i] = 46
+		} else {
+			subs[i] = 55 + rng.Intn(34)
+		}
+	}
+	sort.Ints(subs)
+	return subs
+}
+
+// timelineScore returns the full-time score and the score at half-time of a
+// timeline played with home as the home side.
+func timelineScore(home models.Team, events []models.MatchEvent) (homeGoals, awayGoals int, halfTime models.Score) {
+	for _, event := range events {
+		if event.Type != models.EventGoal {
+			continue
+		}
+		firstHalf := event.Minute <= 45
+		if event.TeamID == home.ID {
+			homeGoals++
+			if firstHalf {
+				halfTime.HomeGoals++
+			}
+		} else {
+			awayGoals++
+			if firstHalf {
+				halfTime.AwayGoals++
+			}
+		}
+	}
+	return homeGoals, awayGoals, halfTime
+}
+
+// PlayTimeline plays a match minute by minute; see playTimeline.
+func (e *PoissonEngine) PlayTimeline(home, away models.Team, rng *rand.Rand) []models.MatchEvent {
+	return playTimeline(e, home, away, rng)
+}
+
+// PlayTimeline plays a match minute by minute; see playTimeline.
+func (e *EloEngine) PlayTimeline(home, away models.Team, rng *rand.Rand) []models.MatchEvent {
+	return playTimeline(e, home, away, rng)
+}
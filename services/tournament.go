@@ -0,0 +1,613 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"league-simulator/models"
+)
+
+var (
+	// ErrTournamentNotFound is returned when no tournament has the requested ID.
+	ErrTournamentNotFound = errors.New("tournament not found")
+	// ErrInvalidTournament is returned for group, pot or advance settings
+	// that cannot form a tournament, including draws the country
+	// constraints make impossible.
+	ErrInvalidTournament = errors.New("invalid tournament")
+	// ErrTournamentFinished is returned by SimulateNext once the final is decided.
+	ErrTournamentFinished = errors.New("tournament finished: nothing left to simulate")
+)
+
+// Tournament stages.
+const (
+	StageGroups   = "group_stage"
+	StageKnockout = "knockout"
+	StageFinished = "finished"
+)
+
+// DefaultGroupAdvance is how many teams of each group reach the knockout
+// stage by default.
+const DefaultGroupAdvance = 2
+
+// maxDrawSteps bounds the search for a draw that meets the constraints.
+const maxDrawSteps = 1_000_000
+
+// Entrant is a team entered into a tournament. Pot is the pot it is drawn
+// from, counted from 1; two teams of the same Country are never drawn into
+// the same group.
+type Entrant struct {
+	Team    models.Team
+	Country string
+	Pot     int
+}
+
+// TournamentOptions configures a tournament.
+type TournamentOptions struct {
+	// Groups is the number of groups; by default one per four entrants.
+	Groups int
+	// Advance is how many teams of each group go through, DefaultGroupAdvance
+	// by default.
+	Advance int
+	// Legs is the number of legs of each knockout tie; see CupOptions.
+	Legs int
+	// Seed defaults to the current time.
+	Seed *int64
+	// Engine defaults to a PoissonEngine.
+	Engine MatchEngine
+	// Tiebreakers rank the group tables, DefaultTiebreakers by default.
+	Tiebreakers []Tiebreaker
+}
+
+// TournamentGroup is one group with its current table and fixtures.
+type TournamentGroup struct {
+	Name     string
+	Entrants []Entrant
+	Table    []models.Standing
+	Matches  [][]models.Match
+}
+
+// TournamentGroupState is a group as saved in a TournamentState.
+type TournamentGroupState struct {
+	Name     string
+	Entrants []Entrant
+	League   LeagueState
+}
+
+// TournamentState is everything needed to restore a Tournament.
+type TournamentState struct {
+	ID          int
+	Name        string
+	Seed        int64
+	Advance     int
+	Legs        int
+	Engine      string
+	Tiebreakers []string
+	Entrants    []Entrant
+	Groups      []TournamentGroupState
+	// Knockout is nil until the group stage is complete.
+	Knockout *CupState
+}
+
+// tournamentGroup is a group whose round robin is played by its own
+// league simulator.
+type tournamentGroup struct {
+	name     string
+	entrants []Entrant
+	league   *SimulatorImpl
+}
+
+// Tournament is a group stage followed by a knockout bracket between the
+// top teams of each group. It is safe for concurrent use.
+type Tournament struct {
+	mu          sync.RWMutex
+	id          int
+	name        string
+	seed        int64
+	advance     int
+	legs        int
+	engine      MatchEngine
+	tiebreakers []Tiebreaker
+	entrants    []Entrant
+	groups      []tournamentGroup
+	knockout    *Cup
+	repo        Repository
+}
+
+// NewTournament draws entrants into groups. Entrants without a pot are put
+// into pots in the order given, one pot per Groups teams; either every
+// entrant has a pot or none does.
+func NewTournament(entrants []Entrant, opts TournamentOptions) (*Tournament, error) {
+	teams := make([]models.Team, len(entrants))
+	for i, entrant := range entrants {
+		teams[i] = entrant.Team
+	}
+	teams, err := normaliseTeams(teams)
+	if err != nil {
+		return nil, err
+	}
+	entrants = append([]Entrant(nil), entrants...)
+	for i := range entrants {
+		entrants[i].Team = teams[i]
+	}
+
+	if opts.Groups == 0 {
+		opts.Groups = max(len(entrants)/4, 1)
+	}
+	if opts.Groups < 1 || len(entrants) < 2*opts.Groups {
+		return nil, fmt.Errorf("%w: %d teams cannot fill %d groups of at least 2", ErrInvalidTournament, len(entrants), opts.Groups)
+	}
+	if opts.Advance == 0 {
+		opts.Advance = DefaultGroupAdvance
+	}
+	if opts.Advance < 1 || opts.Advance > len(entrants)/opts.Groups || opts.Advance*opts.Groups < 2 {
+		return nil, fmt.Errorf("%w: cannot advance %d teams from each of %d groups", ErrInvalidTournament, opts.Advance, opts.Groups)
+	}
+	if opts.Engine == nil {
+		opts.Engine = NewPoissonEngine()
+	}
+	if opts.Tiebreakers == nil {
+		opts.Tiebreakers = DefaultTiebreakers
+	}
+	if opts.Legs == 0 {
+		opts.Legs = 1
+	}
+	if opts.Legs != 1 && opts.Legs != 2 {
+		return nil, fmt.Errorf("%w: ties must have 1 or 2 legs", ErrInvalidTournament)
+	}
+	if err := assignPots(entrants, opts.Groups); err != nil {
+		return nil, err
+	}
+	seed := time.Now().UnixNano()
+	if opts.Seed != nil {
+		seed = *opts.Seed
+	}
+
+	t := &Tournament{
+		seed:        seed,
+		advance:     opts.Advance,
+		legs:        opts.Legs,
+		engine:      opts.Engine,
+		tiebreakers: opts.Tiebreakers,
+		entrants:    entrants,
+	}
+	drawn, err := drawGroups(entrants, opts.Groups, rand.New(rand.NewSource(seed)))
+	if err != nil {
+		return nil, err
+	}
+	for i, group := range drawn {
+		name := groupName(i)
+		teams := make([]models.Team, len(group))
+		for j, entrant := range group {
+			teams[j] = entrant.Team
+		}
+		league := newSimulator(teams,
+			WithName("Group "+name),
+			WithSeed(seed+int64(i)+1),
+			WithMatchEngine(t.engine),
+			WithTiebreakers(t.tiebreakers),
+		)
+		t.groups = append(t.groups, tournamentGroup{name: name, entrants: group, league: league})
+	}
+	return t, nil
+}
+
+// restoreTournament rebuilds a tournament from saved state.
+func restoreTournament(state TournamentState) *Tournament {
+	t := &Tournament{
+		id:          state.ID,
+		name:        state.Name,
+		seed:        state.Seed,
+		advance:     state.Advance,
+		legs:        state.Legs,
+		engine:      NewPoissonEngine(),
+		tiebreakers: DefaultTiebreakers,
+		entrants:    state.Entrants,
+	}
+	if engine, err := ParseEngine(state.Engine); err == nil {
+		t.engine = engine
+	}
+	if rules, err := ParseTiebreakers(state.Tiebreakers); err == nil && len(rules) > 0 {
+		t.tiebreakers = rules
+	}
+	for _, group := range state.Groups {
+		t.groups = append(t.groups, tournamentGroup{
+			name:     group.Name,
+			entrants: group.Entrants,
+			league:   restoreSimulator(group.League),
+		})
+	}
+	if state.Knockout != nil {
+		t.knockout = restoreCup(*state.Knockout)
+	}
+	return t
+}
+
+// assignPots fills in pots by order when no entrant has one, and checks no
+// pot holds more teams than there are groups.
+func assignPots(entrants []Entrant, groups int) error {
+	withPot := 0
+	for _, entrant := range entrants {
+		if entrant.Pot != 0 {
+			withPot++
+		}
+	}
+	switch withPot {
+	case 0:
+		for i := range entrants {
+			entrants[i].Pot = i/groups + 1
+		}
+	case len(entrants):
+	default:
+		return fmt.Errorf("%w: either every team or no team must have a pot", ErrInvalidTournament)
+	}
+
+	sizes := make(map[int]int)
+	for _, entrant := range entrants {
+		if entrant.Pot < 1 {
+			return fmt.Errorf("%w: pots are numbered from 1", ErrInvalidTournament)
+		}
+		sizes[entrant.Pot]++
+		if sizes[entrant.Pot] > groups {
+			return fmt.Errorf("%w: pot %d has more teams than there are groups", ErrInvalidTournament, entrant.Pot)
+		}
+	}
+	return nil
+}
+
+// drawGroups draws the pots in order, each shuffled, and puts every team
+// into the first group, alphabetically, that has no team from its pot or
+// country, is not already full, and still leaves a valid place for every
+// team yet to be drawn. Group sizes differ by at most one: when the teams
+// do not divide evenly, only len(entrants) % groups groups take the extra
+// team.
+func drawGroups(entrants []Entrant, groups int, rng *rand.Rand) ([][]Entrant, error) {
+	order := append([]Entrant(nil), entrants...)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Pot < order[j].Pot })
+	for start := 0; start < len(order); {
+		end := start
+		for end < len(order) && order[end].Pot == order[start].Pot {
+			end++
+		}
+		pot := order[start:end]
+		rng.Shuffle(len(pot), func(i, j int) { pot[i], pot[j] = pot[j], pot[i] })
+		start = end
+	}
+
+	capacity := (len(order) + groups - 1) / groups
+	// With a remainder, only that many groups may grow to capacity.
+	larger, full := len(order)%groups, 0
+	drawn := make([][]Entrant, groups)
+	steps := 0
+	var place func(k int) bool
+	place = func(k int) bool {
+		if k == len(order) {
+			return true
+		}
+		if steps++; steps > maxDrawSteps {
+			return false
+		}
+		entrant := order[k]
+		for g := range drawn {
+			if len(drawn[g]) == capacity || clashes(drawn[g], entrant) {
+				continue
+			}
+			fills := larger > 0 && len(drawn[g]) == capacity-1
+			if fills && full == larger {
+				continue
+			}
+			if fills {
+				full++
+			}
+			drawn[g] = append(drawn[g], entrant)
+			if place(k + 1) {
+				return true
+			}
+			drawn[g] = drawn[g][:len(drawn[g])-1]
+			if fills {
+				full--
+			}
+		}
+		return false
+	}
+	if !place(0) {
+		return nil, fmt.Errorf("%w: no draw keeps pots and countries apart", ErrInvalidTournament)
+	}
+	return drawn, nil
+}
+
+// clashes reports whether entrant shares a pot or country with anyone in group.
+func clashes(group []Entrant, entrant Entrant) bool {
+	for _, other := range group {
+		if other.Pot == entrant.Pot || (entrant.Country != "" && other.Country == entrant.Country) {
+			return true
+		}
+	}
+	return false
+}
+
+// groupName returns A, B, ..., Z, AA, AB, ...
+func groupName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// SimulateNext plays the next matchday in every group, or the next knockout
+// round once the group stage is over, and returns the name of what it
+// played. The knockout bracket is drawn as soon as the last group match
+// has been played.
+func (t *Tournament) SimulateNext() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	played, err := t.simulateNext()
+	if err != nil {
+		return "", err
+	}
+	t.persist()
+	return played, nil
+}
+
+// SimulateAll plays the rest of the tournament.
+func (t *Tournament) SimulateAll() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for {
+		if _, err := t.simulateNext(); err != nil {
+			break
+		}
+	}
+	t.persist()
+}
+
+// simulateNext is SimulateNext without the persistence. Callers must hold mu.
+func (t *Tournament) simulateNext() (string, error) {
+	if t.knockout != nil {
+		round, err := t.knockout.SimulateRound()
+		if errors.Is(err, ErrCupFinished) {
+			return "", ErrTournamentFinished
+		}
+		return round.Name, err
+	}
+
+	matchday := 0
+	for _, group := range t.groups {
+		group.league.mu.Lock()
+		week := group.league.firstUnplayedWeek() + 1
+		if group.league.simulateWeek() {
+			matchday = max(matchday, week)
+		}
+		group.league.mu.Unlock()
+	}
+	if t.groupsFinished() {
+		if err := t.drawKnockout(); err != nil {
+			return "", err
+		}
+	}
+	return fmt.Sprintf("Matchday %d", matchday), nil
+}
+
+// groupsFinished reports whether every group match has been played. Callers
+// must hold mu.
+func (t *Tournament) groupsFinished() bool {
+	for _, group := range t.groups {
+		group.league.mu.RLock()
+		finished := group.league.firstUnplayedWeek() >= len(group.league.matches)
+		group.league.mu.RUnlock()
+		if !finished {
+			return false
+		}
+	}
+	return true
+}
+
+// qualifier is a team that came through the group stage.
+type qualifier struct {
+	standing models.Standing
+	group    int
+}
+
+// drawKnockout seeds the qualifiers, group winners first, and within each
+// finishing place by points, goal difference and goals scored. Seeds meet
+// as in a seeded cup, except that teams from the same group are kept apart
+// in the first round where a swap allows it. Callers must hold mu.
+func (t *Tournament) drawKnockout() error {
+	var seeds []qualifier
+	for place := 0; place < t.advance; place++ {
+		var tier []qualifier
+		for g, group := range t.groups {
+			table := group.league.GetStandings()
+			if place < len(table) {
+				tier = append(tier, qualifier{standing: table[place], group: g})
+			}
+		}
+		sort.SliceStable(tier, func(i, j int) bool {
+			a, b := tier[i].standing, tier[j].standing
+			if a.Points != b.Points {
+				return a.Points > b.Points
+			}
+			if a.GoalDiff != b.GoalDiff {
+				return a.GoalDiff > b.GoalDiff
+			}
+			return a.GoalsFor > b.GoalsFor
+		})
+		seeds = append(seeds, tier...)
+	}
+
+	size := 1
+	for size < len(seeds) {
+		size *= 2
+	}
+	slots := make([]*qualifier, size)
+	for i, seed := range seedPositions(size) {
+		if seed < len(seeds) {
+			slots[i] = &seeds[seed]
+		}
+	}
+	separateGroups(slots)
+
+	teams := make([]models.Team, size)
+	for i, slot := range slots {
+		if slot != nil {
+			teams[i] = slot.standing.Team
+		}
+	}
+	seed := t.seed + int64(len(t.groups)) + 1
+	knockout, err := newCupFromSlots(teams, CupOptions{Legs: t.legs, Seed: &seed, Engine: t.engine})
+	if err != nil {
+		return err
+	}
+	knockout.name = t.name
+	t.knockout = knockout
+	return nil
+}
+
+// separateGroups swaps the away sides of first-round ties so that, where
+// possible, no tie is between two teams of the same group.
+func separateGroups(slots []*qualifier) {
+	sameGroup := func(home, away *qualifier) bool {
+		return home != nil && away != nil && home.group == away.group
+	}
+	for i := 0; i < len(slots); i += 2 {
+		if !sameGroup(slots[i], slots[i+1]) {
+			continue
+		}
+		for j := 0; j < len(slots); j += 2 {
+			if j == i || slots[j+1] == nil {
+				continue
+			}
+			if !sameGroup(slots[i], slots[j+1]) && !sameGroup(slots[j], slots[i+1]) {
+				slots[i+1], slots[j+1] = slots[j+1], slots[i+1]
+				break
+			}
+		}
+	}
+}
+
+// ID returns the tournament's registry ID.
+func (t *Tournament) ID() int {
+	return t.id
+}
+
+// Name returns the tournament's display name.
+func (t *Tournament) Name() string {
+	return t.name
+}
+
+// Seed returns the seed the draw and every match come from.
+func (t *Tournament) Seed() int64 {
+	return t.seed
+}
+
+// Advance returns how many teams of each group reach the knockout stage.
+func (t *Tournament) Advance() int {
+	return t.advance
+}
+
+// Legs returns the number of legs of each knockout tie.
+func (t *Tournament) Legs() int {
+	return t.legs
+}
+
+// Engine returns the match engine every match is played with.
+func (t *Tournament) Engine() MatchEngine {
+	return t.engine
+}
+
+// Tiebreakers returns the rules the group tables are ranked with.
+func (t *Tournament) Tiebreakers() []Tiebreaker {
+	return t.tiebreakers
+}
+
+// Stage returns StageGroups, StageKnockout or StageFinished.
+func (t *Tournament) Stage() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.knockout == nil {
+		return StageGroups
+	}
+	if _, ok := t.knockout.Champion(); ok {
+		return StageFinished
+	}
+	return StageKnockout
+}
+
+// Groups returns every group with its table and fixtures.
+func (t *Tournament) Groups() []TournamentGroup {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	groups := make([]TournamentGroup, len(t.groups))
+	for i, group := range t.groups {
+		snapshot := group.league.Snapshot()
+		groups[i] = TournamentGroup{
+			Name:     group.name,
+			Entrants: append([]Entrant(nil), group.entrants...),
+			Table:    snapshot.Table,
+			Matches:  snapshot.Matches,
+		}
+	}
+	return groups
+}
+
+// Knockout returns the knockout bracket, or nil during the group stage.
+func (t *Tournament) Knockout() *Cup {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.knockout
+}
+
+// state copies the tournament into a TournamentState. Callers must hold mu.
+func (t *Tournament) state() TournamentState {
+	state := TournamentState{
+		ID:          t.id,
+		Name:        t.name,
+		Seed:        t.seed,
+		Advance:     t.advance,
+		Legs:        t.legs,
+		Engine:      EngineName(t.engine),
+		Tiebreakers: TiebreakerNames(t.tiebreakers),
+		Entrants:    append([]Entrant(nil), t.entrants...),
+	}
+	for _, group := range t.groups {
+		group.league.mu.RLock()
+		state.Groups = append(state.Groups, TournamentGroupState{
+			Name:     group.name,
+			Entrants: append([]Entrant(nil), group.entrants...),
+			League:   group.league.state(),
+		})
+		group.league.mu.RUnlock()
+	}
+	if t.knockout != nil {
+		t.knockout.mu.RLock()
+		knockout := t.knockout.state()
+		t.knockout.mu.RUnlock()
+		state.Knockout = &knockout
+	}
+	return state
+}
+
+// persist writes the tournament through to the repository, if one is
+// configured. Callers must hold mu.
+func (t *Tournament) persist() {
+	if t.repo == nil {
+		return
+	}
+	if err := t.repo.SaveTournament(t.state()); err != nil {
+		log.Printf("failed to persist tournament %d: %v", t.id, err)
+	}
+}
+
+// detach stops the tournament writing through to its repository.
+func (t *Tournament) detach() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.repo = nil
+}
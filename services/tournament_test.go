@@ -0,0 +1,50 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDrawGroupsBalancesSizes(t *testing.T) {
+	for teams := 4; teams <= 24; teams++ {
+		for groups := 1; 2*groups <= teams; groups++ {
+			// Potted in order, or with every team in a pot of its own,
+			// which once filled the first groups and left 3, 3, 1.
+			for _, ownPots := range []bool{false, true} {
+				t.Run(fmt.Sprintf("%d teams in %d groups, own pots %t", teams, groups, ownPots), func(t *testing.T) {
+					entrants := make([]Entrant, teams)
+					for i, team := range testTeams(teams) {
+						entrants[i] = Entrant{Team: team}
+						if ownPots {
+							entrants[i].Pot = i + 1
+						}
+					}
+					if err := assignPots(entrants, groups); err != nil {
+						t.Fatal(err)
+					}
+					drawn, err := drawGroups(entrants, groups, rand.New(rand.NewSource(int64(teams))))
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, group := range drawn {
+						if size := len(group); size < 2 || size < teams/groups || size > (teams+groups-1)/groups {
+							t.Errorf("drew a group of %d", size)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestNewTournamentRejectsGroupsOfOne(t *testing.T) {
+	entrants := make([]Entrant, 5)
+	for i, team := range testTeams(5) {
+		entrants[i] = Entrant{Team: team}
+	}
+	if _, err := NewTournament(entrants, TournamentOptions{Groups: 3}); !errors.Is(err, ErrInvalidTournament) {
+		t.Errorf("5 teams in 3 groups: got %v, want ErrInvalidTournament", err)
+	}
+}